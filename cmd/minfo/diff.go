@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+type difference struct {
+	Kind string `json:"kind"` // added, removed, changed or value
+	Key  string `json:"key"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+	// max absolute difference of the tensors with the same type and shapes
+	MaxDiff float64 `json:"max_diff,omitempty"`
+}
+
+type differ struct {
+	list []difference
+}
+
+func (d *differ) added(key, value string) {
+	d.list = append(d.list, difference{Kind: "added", Key: key, New: value})
+}
+
+func (d *differ) removed(key, value string) {
+	d.list = append(d.list, difference{Kind: "removed", Key: key, Old: value})
+}
+
+func (d *differ) compare(key, old, new string) {
+	if old != new {
+		d.list = append(d.list, difference{Kind: "changed", Key: key, Old: old, New: new})
+	}
+}
+
+func formatParam(p paramInfo) string {
+	return p.Type + formatShapes(p.Shapes)
+}
+
+func (d *differ) layer(a, b layerInfo) {
+	d.compare(a.Name+".class", a.Class, b.Class)
+	for _, k := range sortedKeys(a.Args) {
+		v, ok := b.Args[k]
+		if !ok {
+			d.removed(a.Name+".args."+k, fmt.Sprintf("%g", a.Args[k]))
+			continue
+		}
+		d.compare(a.Name+".args."+k, fmt.Sprintf("%g", a.Args[k]), fmt.Sprintf("%g", v))
+	}
+	for _, k := range sortedKeys(b.Args) {
+		if _, ok := a.Args[k]; !ok {
+			d.added(a.Name+".args."+k, fmt.Sprintf("%g", b.Args[k]))
+		}
+	}
+	params := make(map[string]paramInfo, len(b.Params))
+	for _, p := range b.Params {
+		params[p.Name] = p
+	}
+	for _, p := range a.Params {
+		other, ok := params[p.Name]
+		if !ok {
+			d.removed(p.Name, formatParam(p))
+			continue
+		}
+		delete(params, p.Name)
+		d.compare(p.Name, formatParam(p), formatParam(other))
+	}
+	for _, p := range b.Params {
+		if _, ok := params[p.Name]; ok {
+			d.added(p.Name, formatParam(p))
+		}
+	}
+}
+
+func optimizerClass(info *optimizerInfo) string {
+	if info == nil {
+		return ""
+	}
+	return info.Class
+}
+
+func schedulerClass(info *schedulerInfo) string {
+	if info == nil {
+		return ""
+	}
+	return info.Class
+}
+
+// diff compares the definition of two models, layers are matched by name
+func diff(a, b *modelInfo) []difference {
+	var d differ
+	layers := make(map[string]layerInfo, len(b.Layers))
+	for _, l := range b.Layers {
+		layers[l.Name] = l
+	}
+	for _, l := range a.Layers {
+		other, ok := layers[l.Name]
+		if !ok {
+			d.removed(l.Name, l.Class)
+			continue
+		}
+		delete(layers, l.Name)
+		d.layer(l, other)
+	}
+	for _, l := range b.Layers {
+		if _, ok := layers[l.Name]; ok {
+			d.added(l.Name, l.Class)
+		}
+	}
+	d.compare("count", fmt.Sprintf("%d", a.Count), fmt.Sprintf("%d", b.Count))
+	d.compare("optimizer", optimizerClass(a.Optimizer), optimizerClass(b.Optimizer))
+	if a.Optimizer != nil && b.Optimizer != nil {
+		for _, k := range sortedKeys(a.Optimizer.Options) {
+			d.compare("optimizer."+k, fmt.Sprintf("%g", a.Optimizer.Options[k]),
+				fmt.Sprintf("%g", b.Optimizer.Options[k]))
+		}
+	}
+	d.compare("scheduler", schedulerClass(a.Scheduler), schedulerClass(b.Scheduler))
+	return d.list
+}
+
+// diffValues compares the tensors with the same key, type and shapes in two state dicts,
+// other tensors are already reported by diff
+func diffValues(a, b map[string]*tensor.Tensor) []difference {
+	var list []difference
+	for _, k := range sortedKeys(a) {
+		x, y := a[k], b[k]
+		if y == nil || x.ScalarType() != y.ScalarType() ||
+			formatShapes(x.Shapes()) != formatShapes(y.Shapes()) {
+			continue
+		}
+		xs := x.ToScalarType(consts.KDouble).Float64Value()
+		ys := y.ToScalarType(consts.KDouble).Float64Value()
+		var max float64
+		for i := range xs {
+			max = math.Max(max, math.Abs(xs[i]-ys[i]))
+		}
+		if max > 0 {
+			list = append(list, difference{Kind: "value", Key: k, MaxDiff: max})
+		}
+	}
+	return list
+}
+
+func printDiff(w io.Writer, list []difference) {
+	if len(list) == 0 {
+		fmt.Fprintln(w, "no difference")
+		return
+	}
+	for _, diff := range list {
+		switch diff.Kind {
+		case "added":
+			fmt.Fprintf(w, "+ %s: %s\n", diff.Key, diff.New)
+		case "removed":
+			fmt.Fprintf(w, "- %s: %s\n", diff.Key, diff.Old)
+		case "value":
+			fmt.Fprintf(w, "~ %s: max abs diff %g\n", diff.Key, diff.MaxDiff)
+		default:
+			fmt.Fprintf(w, "~ %s: %s -> %s\n", diff.Key, diff.Old, diff.New)
+		}
+	}
+}
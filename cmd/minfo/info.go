@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/tnn/internal/pb"
+	"github.com/lwch/tnn/nn/net"
+)
+
+type paramInfo struct {
+	Name   string  `json:"name"`
+	Type   string  `json:"type"`
+	Shapes []int64 `json:"shapes"`
+	Count  int64   `json:"count"`
+	Bytes  int64   `json:"bytes"`
+}
+
+type layerInfo struct {
+	Name   string             `json:"name"`
+	Class  string             `json:"class"`
+	Args   map[string]float32 `json:"args,omitempty"`
+	Params []paramInfo        `json:"params,omitempty"`
+	// non-trainable tensors, e.g. the running statistics of BatchNorm,
+	// they are not counted in Count and Bytes
+	Buffers []paramInfo `json:"buffers,omitempty"`
+	Count   int64       `json:"count"`
+	Bytes   int64       `json:"bytes"`
+}
+
+type optimizerInfo struct {
+	Class   string             `json:"class"`
+	Options map[string]float64 `json:"options,omitempty"`
+	State   []paramInfo        `json:"state,omitempty"`
+	Bytes   int64              `json:"bytes"`
+}
+
+type schedulerInfo struct {
+	Class string `json:"class"`
+	Bytes int64  `json:"bytes"`
+}
+
+type modelInfo struct {
+	File      string         `json:"file"`
+	FileSize  int64          `json:"file_size"`
+	Layers    []layerInfo    `json:"layers"`
+	Count     int64          `json:"count"` // count of params
+	Bytes     int64          `json:"bytes"` // bytes of params
+	Optimizer *optimizerInfo `json:"optimizer,omitempty"`
+	Scheduler *schedulerInfo `json:"scheduler,omitempty"`
+}
+
+func elemSize(t consts.ScalarType) int64 {
+	switch t {
+	case consts.KUint8, consts.KInt8, consts.KBool:
+		return 1
+	case consts.KInt16, consts.KHalf, consts.KBFloat16:
+		return 2
+	case consts.KInt32, consts.KFloat:
+		return 4
+	case consts.KInt64, consts.KDouble:
+		return 8
+	default:
+		return 0
+	}
+}
+
+func newParamInfo(p *pb.Param, name string) paramInfo {
+	t := consts.ScalarType(p.GetType())
+	return paramInfo{
+		Name:   name,
+		Type:   t.String(),
+		Shapes: p.GetShapes(),
+		Count:  p.GetElemCount(),
+		Bytes:  p.GetElemCount() * elemSize(t),
+	}
+}
+
+// optimizerOptions decodes the options of the optimizers shipped by gotorch
+func optimizerOptions(class string, data []byte) map[string]float64 {
+	var names []string
+	switch class {
+	case "Adam":
+		names = []string{"lr", "weight_decay", "beta1", "beta2", "eps"}
+	case "AdamW":
+		names = []string{"lr", "weight_decay", "beta1", "beta2", "eps", "amsgrad"}
+	default:
+		return nil
+	}
+	r := bytes.NewReader(data)
+	ret := make(map[string]float64, len(names))
+	for _, name := range names {
+		var v float64
+		if name == "amsgrad" {
+			var b bool
+			if binary.Read(r, binary.LittleEndian, &b) != nil {
+				return nil
+			}
+			if b {
+				v = 1
+			}
+		} else if binary.Read(r, binary.LittleEndian, &v) != nil {
+			return nil
+		}
+		ret[name] = v
+	}
+	return ret
+}
+
+func readSpec(dir string) (*pb.Net, int64, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	spec, err := net.ReadSpec(f, fi.Size())
+	if err != nil {
+		return nil, 0, err
+	}
+	return spec, fi.Size(), nil
+}
+
+// load reads the model definition without loading the params
+func load(dir string) (*modelInfo, error) {
+	spec, size, err := readSpec(dir)
+	if err != nil {
+		return nil, err
+	}
+	ret := modelInfo{
+		File:     dir,
+		FileSize: size,
+	}
+	for _, l := range spec.GetLayers() {
+		info := layerInfo{
+			Name:  l.GetName(),
+			Class: l.GetClass(),
+			Args:  l.GetArgs(),
+		}
+		for i, p := range l.GetParams() {
+			name := p.GetName()
+			if len(name) == 0 {
+				name = fmt.Sprintf("%s.%d", l.GetName(), i)
+			}
+			param := newParamInfo(p, name)
+			info.Params = append(info.Params, param)
+			info.Count += param.Count
+			info.Bytes += param.Bytes
+		}
+		for _, p := range l.GetBuffers() {
+			info.Buffers = append(info.Buffers, newParamInfo(p, p.GetName()))
+		}
+		ret.Layers = append(ret.Layers, info)
+		ret.Count += info.Count
+		ret.Bytes += info.Bytes
+	}
+	if optm := spec.GetOptimizer(); optm != nil {
+		info := optimizerInfo{
+			Class:   optm.GetClass(),
+			Options: optimizerOptions(optm.GetClass(), optm.GetOptions()),
+		}
+		for i, params := range optm.GetParams() {
+			for j, p := range params.GetParams() {
+				param := newParamInfo(p, fmt.Sprintf("%d.%d", i, j))
+				info.State = append(info.State, param)
+				info.Bytes += param.Bytes
+			}
+		}
+		ret.Optimizer = &info
+	}
+	if s := spec.GetScheduler(); s != nil {
+		ret.Scheduler = &schedulerInfo{
+			Class: s.GetClass(),
+			Bytes: int64(len(s.GetState())),
+		}
+	}
+	return &ret, nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
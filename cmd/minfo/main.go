@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/runtime"
+	"github.com/lwch/tnn/nn/net"
+	"github.com/spf13/cobra"
+)
+
+var rootCmd = cobra.Command{
+	Use:   "minfo [model]",
+	Short: "show the definition of the model saved by tnn",
+	Args:  cobra.ExactArgs(1),
+	Run:   run,
+}
+
+var jsonOutput bool
+var diffModel string
+
+func main() {
+	rootCmd.Flags().BoolVar(&jsonOutput, "json", false, "output as json")
+	rootCmd.Flags().StringVar(&diffModel, "diff", "", "compare the definition and tensors with another model")
+
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	runtime.Assert(rootCmd.Execute())
+}
+
+func run(_ *cobra.Command, args []string) {
+	info, err := load(args[0])
+	runtime.Assert(err)
+	if len(diffModel) == 0 {
+		if jsonOutput {
+			writeJSON(info)
+			return
+		}
+		printInfo(os.Stdout, info)
+		return
+	}
+	other, err := load(diffModel)
+	runtime.Assert(err)
+	list := diff(info, other)
+	a, err := net.New(consts.KCPU).ReadStateDictFile(args[0])
+	runtime.Assert(err)
+	b, err := net.New(consts.KCPU).ReadStateDictFile(diffModel)
+	runtime.Assert(err)
+	list = append(list, diffValues(a, b)...)
+	if jsonOutput {
+		writeJSON(list)
+		return
+	}
+	printDiff(os.Stdout, list)
+}
+
+func writeJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	runtime.Assert(enc.Encode(v))
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/internal/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func writeModel(t *testing.T, dir string, spec *pb.Net) string {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	data, err := proto.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: "SPEC", Method: zip.Store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), dir)
+	if err = os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func linear(name string, output int64, bias bool) *pb.Layer {
+	l := &pb.Layer{
+		Class: "linear",
+		Name:  name,
+		Args:  map[string]float32{"bias": 0},
+		Params: []*pb.Param{{
+			Name:      name + ".w",
+			Type:      uint32(consts.KFloat),
+			ElemCount: output * 2,
+			Shapes:    []int64{output, 2},
+		}},
+	}
+	if bias {
+		l.Args["bias"] = 1
+		l.Params = append(l.Params, &pb.Param{
+			Name:      name + ".b",
+			Type:      uint32(consts.KFloat),
+			ElemCount: output,
+			Shapes:    []int64{output},
+		})
+	}
+	return l
+}
+
+func TestLoad(t *testing.T) {
+	path := writeModel(t, "a.model", &pb.Net{
+		Layers: []*pb.Layer{linear("hidden", 4, true), {Class: "relu", Name: "relu"}},
+		Optimizer: &pb.Optimizer{
+			Class:   "Adam",
+			Options: make([]byte, 5*8),
+		},
+		Scheduler: &pb.Scheduler{Class: "cosine", State: make([]byte, 32)},
+	})
+	info, err := load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Layers) != 2 || info.Count != 12 || info.Bytes != 48 {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if info.Optimizer == nil || len(info.Optimizer.Options) != 5 {
+		t.Fatalf("unexpected optimizer: %+v", info.Optimizer)
+	}
+	if info.Scheduler == nil || info.Scheduler.Class != "cosine" {
+		t.Fatalf("unexpected scheduler: %+v", info.Scheduler)
+	}
+	var out strings.Builder
+	printInfo(&out, info)
+	if !strings.Contains(out.String(), "hidden.w") {
+		t.Fatalf("missing param in output:\n%s", out.String())
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a, err := load(writeModel(t, "a.model", &pb.Net{
+		Layers: []*pb.Layer{linear("hidden", 4, true), linear("output", 1, true)},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := load(writeModel(t, "b.model", &pb.Net{
+		Layers: []*pb.Layer{linear("hidden", 8, false), linear("head", 1, true)},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, d := range diff(a, b) {
+		got[d.Key] = d.Kind
+	}
+	expect := map[string]string{
+		"hidden.args.bias": "changed",
+		"hidden.w":         "changed",
+		"hidden.b":         "removed",
+		"output":           "removed",
+		"head":             "added",
+		"count":            "changed",
+	}
+	for k, kind := range expect {
+		if got[k] != kind {
+			t.Fatalf("unexpected diff of %s: %q, expected %q, got %v", k, got[k], kind, got)
+		}
+	}
+	if len(diff(a, a)) != 0 {
+		t.Fatal("unexpected diff of the same model")
+	}
+}
+
+func TestDiffValues(t *testing.T) {
+	a := map[string]*tensor.Tensor{
+		"hidden.w": tensor.FromFloat32([]float32{1, 2, 3, 4}, tensor.WithShapes(2, 2)),
+		"hidden.b": tensor.FromFloat32([]float32{1, 2}, tensor.WithShapes(2)),
+		"output.w": tensor.FromFloat32([]float32{1, 2}, tensor.WithShapes(1, 2)),
+	}
+	b := map[string]*tensor.Tensor{
+		"hidden.w": tensor.FromFloat32([]float32{1, 2.5, 3, 3}, tensor.WithShapes(2, 2)),
+		"hidden.b": tensor.FromFloat32([]float32{1, 2}, tensor.WithShapes(2)),
+		"output.w": tensor.FromFloat32([]float32{1, 2}, tensor.WithShapes(2, 1)),
+	}
+	list := diffValues(a, b)
+	if len(list) != 1 || list[0].Key != "hidden.w" || list[0].Kind != "value" || list[0].MaxDiff != 1 {
+		t.Fatalf("unexpected diff: %+v", list)
+	}
+	if len(diffValues(a, a)) != 0 {
+		t.Fatal("unexpected diff of the same tensors")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+func formatArgs(args map[string]float32) string {
+	var ret []string
+	for _, k := range sortedKeys(args) {
+		ret = append(ret, fmt.Sprintf("%s=%g", k, args[k]))
+	}
+	return strings.Join(ret, ", ")
+}
+
+func formatShapes(shapes []int64) string {
+	ret := make([]string, len(shapes))
+	for i, s := range shapes {
+		ret[i] = fmt.Sprintf("%d", s)
+	}
+	return "[" + strings.Join(ret, ", ") + "]"
+}
+
+func formatBytes(n int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB"}
+	size := float64(n)
+	i := 0
+	for size >= 1024 && i < len(units)-1 {
+		size /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", size, units[i])
+}
+
+func printInfo(w io.Writer, info *modelInfo) {
+	fmt.Fprintf(w, "file: %s (%s)\n", info.File, formatBytes(info.FileSize))
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"name", "class", "args", "param", "type", "shapes", "count"})
+	table.SetAutoWrapText(false)
+	for _, l := range info.Layers {
+		args := formatArgs(l.Args)
+		if len(l.Params) == 0 && len(l.Buffers) == 0 {
+			table.Append([]string{l.Name, l.Class, args, "", "", "", "0"})
+			continue
+		}
+		for i, p := range l.Params {
+			name, class := l.Name, l.Class
+			if i > 0 {
+				name, class, args = "", "", ""
+			}
+			table.Append([]string{name, class, args, p.Name, p.Type,
+				formatShapes(p.Shapes), fmt.Sprintf("%d", p.Count)})
+		}
+		for i, p := range l.Buffers {
+			name, class := l.Name, l.Class
+			if i > 0 || len(l.Params) > 0 {
+				name, class, args = "", "", ""
+			}
+			// buffers are not counted in the total
+			table.Append([]string{name, class, args, p.Name + " (buffer)", p.Type,
+				formatShapes(p.Shapes), "-"})
+		}
+	}
+	table.SetFooter([]string{"", "", "", "", "total",
+		formatBytes(info.Bytes), fmt.Sprintf("%d", info.Count)})
+	table.Render()
+
+	if info.Optimizer != nil {
+		fmt.Fprintf(w, "optimizer: %s, %d state tensors (%s)\n", info.Optimizer.Class,
+			len(info.Optimizer.State), formatBytes(info.Optimizer.Bytes))
+		for _, k := range sortedKeys(info.Optimizer.Options) {
+			fmt.Fprintf(w, "  %s: %g\n", k, info.Optimizer.Options[k])
+		}
+	}
+	if info.Scheduler != nil {
+		fmt.Fprintf(w, "scheduler: %s (%d bytes state)\n", info.Scheduler.Class, info.Scheduler.Bytes)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/lwch/runtime"
 	"github.com/lwch/tnn/example/couplet/logic/feature"
@@ -15,15 +17,15 @@ func (m *Model) Load(dir string) {
 	if _, err := os.Stat(filepath.Join(dir, "couplet.model")); os.IsNotExist(err) {
 		panic("model not found")
 	}
-	var net net.Net
-	runtime.Assert(net.Load(filepath.Join(dir, "couplet.model")))
-	m.loadFrom(&net)
 
 	if _, err := os.Stat(filepath.Join(dir, "vocabs")); os.IsNotExist(err) {
 		panic("vocabs not found")
 	}
 	m.vocabs, m.vocabsIdx = feature.LoadVocab(filepath.Join(dir, "vocabs"))
 
+	m.build()
+	runtime.Assert(m.loadModel(filepath.Join(dir, "couplet.model")))
+
 	if _, err := os.Stat(filepath.Join(dir, "embedding")); os.IsNotExist(err) {
 		panic("embedding not found")
 	}
@@ -31,3 +33,53 @@ func (m *Model) Load(dir string) {
 
 	fmt.Println("model loaded")
 }
+
+// loadModel 按参数名加载模型参数, 旧版本模型中的参数名会被转换为当前的参数名
+func (m *Model) loadModel(dir string) error {
+	params, err := m.net.ReadStateDictFile(dir)
+	if err != nil {
+		return err
+	}
+	params = net.RemapStateDict(params, func(key string) string {
+		return ffnKey(legacyKey(key))
+	})
+	_, err = m.net.LoadStateDict(params, true)
+	return err
+}
+
+// legacyKey 将旧版本模型中的attn.N.xxx转换为blocks.N.xxx,
+// 其中attn.N.q等为attention层的参数
+func legacyKey(key string) string {
+	parts := strings.SplitN(key, ".", 3)
+	if len(parts) != 3 || parts[0] != "attn" {
+		return key
+	}
+	if _, err := strconv.Atoi(parts[1]); err != nil {
+		return key
+	}
+	if !strings.Contains(parts[2], ".") {
+		return "blocks." + parts[1] + ".attn." + parts[2]
+	}
+	return "blocks." + parts[1] + "." + parts[2]
+}
+
+// ffnKeys 旧版本模型中的l1和output两个全连接层合并为ffn层后的参数名
+var ffnKeys = map[string]string{
+	"l1.w":     "ffn.w1",
+	"l1.b":     "ffn.b1",
+	"output.w": "ffn.w2",
+	"output.b": "ffn.b2",
+}
+
+// ffnKey 将旧版本模型中的blocks.N.l1.xxx和blocks.N.output.xxx转换为blocks.N.ffn.xxx,
+// 最后的输出层output.xxx不在blocks下因此不受影响
+func ffnKey(key string) string {
+	parts := strings.SplitN(key, ".", 3)
+	if len(parts) != 3 || parts[0] != "blocks" {
+		return key
+	}
+	if name, ok := ffnKeys[parts[2]]; ok {
+		return "blocks." + parts[1] + "." + name
+	}
+	return key
+}
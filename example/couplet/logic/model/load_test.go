@@ -0,0 +1,163 @@
+package model
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lwch/tnn/nn/layer"
+	"github.com/lwch/tnn/nn/layer/activation"
+	"github.com/lwch/tnn/nn/net"
+	"google.golang.org/protobuf/proto"
+)
+
+// legacyNet 按旧版本的层名和结构生成模型
+func legacyNet(vocabs int) *net.Net {
+	n := net.New(device)
+	for i := 0; i < transformerSize; i++ {
+		norm1 := layer.NewLayerNorm(fmt.Sprintf("attn.%d.norm1", i), embeddingDim, layer.WithBias(false))
+		norm2 := layer.NewLayerNorm(fmt.Sprintf("attn.%d.norm2", i), embeddingDim, layer.WithBias(false))
+		norm1.SetEps(1e-9)
+		norm2.SetEps(1e-9)
+		n.Add(layer.NewAttention(fmt.Sprintf("attn.%d", i), embeddingDim, heads, 0, false),
+			layer.NewLinear(fmt.Sprintf("attn.%d.l1", i), embeddingDim, embeddingDim*4, layer.WithBias(false)),
+			activation.NewReLU(),
+			norm1, norm2,
+			layer.NewLinear(fmt.Sprintf("attn.%d.output", i), embeddingDim*4, embeddingDim, layer.WithBias(false)))
+	}
+	n.Add(activation.NewReLU(), layer.NewLinear("output", embeddingDim, vocabs, layer.WithBias(false)))
+	return n
+}
+
+// legacyArgs 旧版本模型中各层保存的参数
+var legacyArgs = map[string][]string{
+	"linear":    {"output"},
+	"attention": {"dims", "heads", "dropout", "rope", "rope_base"},
+}
+
+// saveLegacy 将模型保存为旧版本格式, 参数没有名称且各层只保存旧版本中存在的参数
+func saveLegacy(t *testing.T, n *net.Net) string {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "new.model")
+	if err := n.Save(src); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, err := net.ReadSpec(f, fi.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range spec.GetLayers() {
+		for _, p := range l.GetParams() {
+			p.Name = ""
+		}
+		args := make(map[string]float32)
+		for _, k := range legacyArgs[l.GetClass()] {
+			args[k] = l.GetArgs()[k]
+		}
+		l.Args = args
+	}
+	data, err := proto.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "couplet.model")
+	out, err := os.Create(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	zw := zip.NewWriter(out)
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "SPEC", Method: zip.Store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(f, fi.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range zr.File {
+		if file.Name == "SPEC" {
+			continue
+		}
+		if err = zw.Copy(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
+
+func TestLoadLegacy(t *testing.T) {
+	const vocabs = 10
+	legacy := legacyNet(vocabs)
+	path := saveLegacy(t, legacy)
+
+	m := New()
+	m.vocabs = make([]string, vocabs)
+	m.build()
+	if err := m.loadModel(path); err != nil {
+		t.Fatal(err)
+	}
+	old := legacy.StateDict()
+	params := m.net.StateDict()
+	expect := map[string]string{
+		"blocks.0.attn.q":  "attn.0.q",
+		"blocks.1.ffn.w1":  "attn.1.l1.w",
+		"blocks.2.ffn.w2":  "attn.2.output.w",
+		"blocks.3.norm2.a": "attn.3.norm2.a",
+		"output.w":         "output.w",
+	}
+	for key, oldKey := range expect {
+		a, b := params[key].Float32Value(), old[oldKey].Float32Value()
+		if len(a) != len(b) {
+			t.Fatalf("%s: unexpected size %d, expected %d", key, len(a), len(b))
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				t.Fatalf("%s: unexpected value at %d: %f != %f", key, i, a[i], b[i])
+			}
+		}
+	}
+}
+
+func TestBuildMask(t *testing.T) {
+	// 旧版本中单个样本的mask
+	const padding = 5
+	expect := make([]float32, paddingSize*paddingSize)
+	for p := padding; p < paddingSize; p++ {
+		for j := 0; j < paddingSize; j++ {
+			expect[p*paddingSize+j] = -1e9
+			expect[j*paddingSize+p] = -1e9
+		}
+	}
+	for y := 0; y < paddingSize; y++ {
+		for x := 0; x < paddingSize; x++ {
+			if x > y {
+				expect[y*paddingSize+x] = -1e9
+			}
+		}
+	}
+	mask := buildMask([]int{padding}).Float32Value()
+	for i := range expect {
+		if mask[i] != expect[i] {
+			t.Fatalf("unexpected mask at %d: %f != %f", i, mask[i], expect[i])
+		}
+	}
+}
@@ -3,10 +3,10 @@ package model
 import (
 	"fmt"
 	"io"
-	"math"
 	_ "net/http/pprof"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync/atomic"
 	"time"
 
@@ -18,6 +18,7 @@ import (
 	"github.com/lwch/tnn/nn/layer"
 	"github.com/lwch/tnn/nn/layer/activation"
 	"github.com/lwch/tnn/nn/net"
+	"github.com/lwch/tnn/nn/scheduler"
 )
 
 const (
@@ -33,6 +34,7 @@ type Model struct {
 	attn   []*transformer
 	relu   *activation.ReLU
 	output *layer.Linear
+	net    *net.Net
 
 	// 运行时
 	epoch    int           // 当前训练到第几个迭代
@@ -46,6 +48,7 @@ type Model struct {
 	samples   []*sample.Sample
 	embedding [][]float32
 	optimizer optimizer.Optimizer
+	scheduler scheduler.Scheduler
 }
 
 // New 创建空模型
@@ -55,22 +58,15 @@ func New() *Model {
 
 // build 生成模型
 func (m *Model) build() {
+	m.net = net.New(device)
+	blocks := m.net.Scope("blocks")
 	for i := 0; i < transformerSize; i++ {
-		m.attn = append(m.attn, newTransformer(i))
+		m.attn = append(m.attn, newTransformer(blocks.Scope(strconv.Itoa(i))))
 	}
 	m.relu = activation.NewReLU()
-	m.output = layer.NewLinear("output", embeddingDim, len(m.vocabs), layer.WithDevice(device))
-}
-
-func (m *Model) params() []*tensor.Tensor {
-	var ret []*tensor.Tensor
-	for _, attn := range m.attn {
-		ret = append(ret, attn.params()...)
-	}
-	for _, p := range m.output.Params() {
-		ret = append(ret, p)
-	}
-	return ret
+	m.output = layer.NewLinear("output", embeddingDim, len(m.vocabs),
+		layer.WithDevice(device), layer.WithBias(false)) // 与旧版本模型保持一致, 不使用bias
+	m.net.Add(m.relu, m.output)
 }
 
 // showProgress 显示进度
@@ -89,13 +85,9 @@ func (m *Model) showProgress() {
 
 // save 保存模型
 func (m *Model) save() {
-	var net net.Net
-	for _, attn := range m.attn {
-		net.Add(attn.layers()...)
-	}
-	net.Add(m.relu, m.output)
-	net.SetOptimizer(m.optimizer)
-	err := net.Save(filepath.Join(m.modelDir, "couplet.model"))
+	m.net.SetOptimizer(m.optimizer)
+	m.net.SetScheduler(m.scheduler)
+	err := m.net.Save(filepath.Join(m.modelDir, "couplet.model"))
 	runtime.Assert(err)
 	fmt.Println("model saved")
 }
@@ -112,26 +104,12 @@ func (m *Model) copyVocabs(dir string) {
 	runtime.Assert(err)
 }
 
-var positionEncoding *tensor.Tensor
-
-func init() {
-	data := make([]float32, paddingSize*embeddingDim)
-	for k := 0; k < paddingSize; k++ {
-		start := k * embeddingDim
-		for i := 0; i < embeddingDim/2; i++ {
-			n := float64(k) / math.Pow(10000, 2*float64(i)/float64(embeddingDim))
-			data[start+i*2] = float32(math.Sin(n))
-			data[start+i*2+1] = float32(math.Cos(n))
-		}
-	}
-	positionEncoding = tensor.FromFloat32(data,
-		tensor.WithShapes(1, paddingSize, embeddingDim),
-		tensor.WithDevice(device))
-}
+// positionEncoding 位置编码
+var positionEncoding = layer.NewSinusoidalEncoding("position", embeddingDim, layer.WithDevice(device))
 
 // forward 正向迭代
 func (m *Model) forward(x *tensor.Tensor, padding []int, train bool) *tensor.Tensor {
-	// x = x.Add(positionEncoding)
+	// x = positionEncoding.Forward(x)
 	y := x
 	for _, attn := range m.attn {
 		y = attn.forward(y, x, padding, train)
@@ -140,16 +118,3 @@ func (m *Model) forward(x *tensor.Tensor, padding []int, train bool) *tensor.Ten
 	y = m.output.Forward(y) // output
 	return y
 }
-
-func (m *Model) loadFrom(net *net.Net) {
-	layers := net.Layers()
-	idx := 0
-	for i := 0; i < transformerSize; i++ {
-		var attn transformer
-		idx = attn.loadFrom(layers, idx)
-		m.attn = append(m.attn, &attn)
-	}
-	m.relu = layers[idx].(*activation.ReLU)
-	idx++
-	m.output = layers[idx].(*layer.Linear)
-}
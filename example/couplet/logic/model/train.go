@@ -13,6 +13,7 @@ import (
 	"github.com/lwch/runtime"
 	"github.com/lwch/tnn/example/couplet/logic/feature"
 	"github.com/lwch/tnn/example/couplet/logic/sample"
+	"github.com/lwch/tnn/nn/scheduler"
 	"github.com/olekukonko/tablewriter"
 )
 
@@ -48,8 +49,10 @@ func (m *Model) Train(sampleDir, modelDir string) {
 
 	m.total = len(m.samples)
 
-	m.optimizer = optimizer.NewAdam(m.params(), optimizer.WithAdamLr(lr))
+	m.optimizer = optimizer.NewAdam(m.net.Params(), optimizer.WithAdamLr(lr))
 	// optimizer := optimizer.NewSGD(lr, 0)
+	// 学习率按余弦曲线在所有迭代中衰减到lr/100
+	m.scheduler = scheduler.NewCosineAnnealing(m.optimizer, epoch, lr/100)
 
 	go m.showProgress()
 
@@ -58,10 +61,11 @@ func (m *Model) Train(sampleDir, modelDir string) {
 		m.epoch = i + 1
 		loss := m.trainEpoch()
 		// m.optimizer.Step(m.params())
+		m.scheduler.Step()
 		m.save()
-		fmt.Printf("train %d, cost=%s, loss=%f\n",
+		fmt.Printf("train %d, cost=%s, loss=%f, lr=%f\n",
 			i+1, time.Since(begin).String(),
-			loss)
+			loss, m.scheduler.GetLr())
 		if i == 0 {
 			m.showModelInfo()
 		}
@@ -154,26 +158,14 @@ func (m *Model) showModelInfo() {
 	defer table.Render()
 	table.SetHeader([]string{"name", "count"})
 	var total int64
-	for _, attn := range m.attn {
-		cnt := paramSize(attn.attn.Params())
-		total += cnt
-		table.Append([]string{attn.attn.Name(), fmt.Sprintf("%d", cnt)})
-		cnt = paramSize(attn.dense.Params())
-		total += cnt
-		table.Append([]string{attn.dense.Name(), fmt.Sprintf("%d", cnt)})
-		cnt = paramSize(attn.output.Params())
-		total += cnt
-		table.Append([]string{attn.output.Name(), fmt.Sprintf("%d", cnt)})
-		cnt = paramSize(attn.norm1.Params())
-		total += cnt
-		table.Append([]string{attn.norm1.Name(), fmt.Sprintf("%d", cnt)})
-		cnt = paramSize(attn.norm2.Params())
+	for _, l := range m.net.Layers() {
+		cnt := paramSize(l.Params())
+		if cnt == 0 {
+			continue
+		}
 		total += cnt
-		table.Append([]string{attn.norm2.Name(), fmt.Sprintf("%d", cnt)})
+		table.Append([]string{l.Name(), fmt.Sprintf("%d", cnt)})
 	}
-	cnt := paramSize(m.output.Params())
-	total += cnt
-	table.Append([]string{m.output.Name(), fmt.Sprintf("%d", cnt)})
 	table.Append([]string{"total", fmt.Sprintf("%d", total)})
 }
 
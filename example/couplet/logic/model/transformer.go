@@ -1,115 +1,68 @@
 package model
 
 import (
-	"fmt"
-
 	"github.com/lwch/gotorch/tensor"
 	"github.com/lwch/tnn/nn/layer"
-	"github.com/lwch/tnn/nn/layer/activation"
+	"github.com/lwch/tnn/nn/net"
 )
 
 type transformer struct {
-	attn   *layer.Attention
-	dense  *layer.Linear
-	relu   *activation.ReLU
-	norm1  *layer.LayerNorm
-	norm2  *layer.LayerNorm
-	output *layer.Linear
+	attn  *layer.Attention
+	ffn   *layer.FeedForward
+	norm1 *layer.LayerNorm
+	norm2 *layer.LayerNorm
 }
 
-func newTransformer(i int) *transformer {
-	attn := layer.NewAttention(fmt.Sprintf("attn.%d", i), embeddingDim, heads, 0, false, layer.WithDevice(device))
-	dense := layer.NewLinear(fmt.Sprintf("attn.%d.l1", i), embeddingDim, embeddingDim*4, layer.WithDevice(device))
-	output := layer.NewLinear(fmt.Sprintf("attn.%d.output", i), embeddingDim*4, embeddingDim, layer.WithDevice(device))
-	norm1 := layer.NewLayerNorm(fmt.Sprintf("attn.%d.norm1", i), embeddingDim, layer.WithDevice(device))
-	norm2 := layer.NewLayerNorm(fmt.Sprintf("attn.%d.norm2", i), embeddingDim, layer.WithDevice(device))
+// newTransformer 创建transformer并将其中的层添加到scope下
+func newTransformer(scope *net.Scope) *transformer {
+	attn := layer.NewAttention("attn", embeddingDim, heads, 0, false, layer.WithDevice(device))
+	// 默认隐藏层为4倍的embeddingDim, 与旧版本模型保持一致, 不使用bias
+	ffn := layer.NewFeedForward("ffn", embeddingDim, layer.WithDevice(device), layer.WithBias(false))
+	norm1 := newLayerNorm("norm1")
+	norm2 := newLayerNorm("norm2")
+	scope.Add(attn, ffn, norm1, norm2)
 	return &transformer{
-		attn:   attn,
-		dense:  dense,
-		relu:   activation.NewReLU(),
-		norm1:  norm1,
-		norm2:  norm2,
-		output: output,
+		attn:  attn,
+		ffn:   ffn,
+		norm1: norm1,
+		norm2: norm2,
 	}
 }
 
-func (t *transformer) forward(q, k *tensor.Tensor, padding []int, train bool) *tensor.Tensor {
-	batchSize := q.Shapes()[0]
-	maskData := make([]float32, batchSize*maskSize)
-	// padding mask
-	for i := 0; i < int(batchSize); i++ {
+// newLayerNorm 创建与旧版本模型一致的layer norm, 不使用bias且eps为1e-9
+func newLayerNorm(name string) *layer.LayerNorm {
+	norm := layer.NewLayerNorm(name, embeddingDim, layer.WithDevice(device), layer.WithBias(false))
+	norm.SetEps(1e-9)
+	return norm
+}
+
+// buildMask 生成padding mask + causal mask, 填充位置所在的行和列以及未来的位置均为-1e9,
+// 与旧版本模型训练时使用的mask一致
+func buildMask(padding []int) *tensor.Tensor {
+	maskSize := paddingSize * paddingSize
+	data := make([]float32, len(padding)*maskSize)
+	for i, n := range padding {
 		start := i * maskSize
-		for p := padding[i]; p < paddingSize; p++ {
-			for j := 0; j < paddingSize; j++ {
-				maskData[start+p*paddingSize+j] = -1e9
-				maskData[start+j*paddingSize+p] = -1e9
+		for y := 0; y < paddingSize; y++ {
+			for x := 0; x < paddingSize; x++ {
+				if y >= n || x >= n || x > y {
+					data[start+y*paddingSize+x] = -1e9
+				}
 			}
 		}
 	}
-	for y := 0; y < paddingSize; y++ {
-		for x := 0; x < paddingSize; x++ {
-			if x > y {
-				maskData[y*paddingSize+x] = -1e9
-			}
-		}
-	}
-	mask := tensor.FromFloat32(maskData,
-		tensor.WithShapes(batchSize, 1, paddingSize, paddingSize),
+	return tensor.FromFloat32(data,
+		tensor.WithShapes(int64(len(padding)), 1, paddingSize, paddingSize),
 		tensor.WithDevice(device))
+}
+
+func (t *transformer) forward(q, k *tensor.Tensor, padding []int, train bool) *tensor.Tensor {
+	mask := buildMask(padding)
 	y := t.attn.Forward(q, k, k, mask, false, train)
 	y = y.Add(q)
 	selfOut := t.norm1.Forward(y)
-	y = t.dense.Forward(y)
-	y = t.relu.Forward(y)
-	y = t.output.Forward(y)
+	y = t.ffn.Forward(y, train)
 	y = y.Add(selfOut)
 	y = t.norm2.Forward(y)
 	return y
 }
-
-func (t *transformer) params() []*tensor.Tensor {
-	var ret []*tensor.Tensor
-	for _, p := range t.attn.Params() {
-		ret = append(ret, p)
-	}
-	for _, p := range t.dense.Params() {
-		ret = append(ret, p)
-	}
-	for _, p := range t.output.Params() {
-		ret = append(ret, p)
-	}
-	for _, p := range t.norm1.Params() {
-		ret = append(ret, p)
-	}
-	for _, p := range t.norm2.Params() {
-		ret = append(ret, p)
-	}
-	return ret
-}
-
-func (t *transformer) layers() []layer.Layer {
-	return []layer.Layer{
-		t.attn,
-		t.dense,
-		t.relu,
-		t.norm1,
-		t.norm2,
-		t.output,
-	}
-}
-
-func (t *transformer) loadFrom(layers []layer.Layer, idx int) int {
-	t.attn = layers[idx].(*layer.Attention)
-	idx++
-	t.dense = layers[idx].(*layer.Linear)
-	idx++
-	t.relu = layers[idx].(*activation.ReLU)
-	idx++
-	t.norm1 = layers[idx].(*layer.LayerNorm)
-	idx++
-	t.norm2 = layers[idx].(*layer.LayerNorm)
-	idx++
-	t.output = layers[idx].(*layer.Linear)
-	idx++
-	return idx
-}
@@ -23,7 +23,7 @@ func newModel() *model {
 		rnn: layer.NewRnn("rnn", featureSize, steps, hiddenSize, layer.WithDevice(device)),
 		// lstm:        layer.NewLstm("lstm", featureSize, steps, hiddenSize, layer.WithDevice(device)),
 		flatten:     layer.NewFlatten("flatten"),
-		outputLayer: layer.NewLinear("output", steps*hiddenSize, 1, layer.WithDevice(device)),
+		outputLayer: layer.NewLinear("output", steps*hiddenSize, 1, layer.WithDevice(device), layer.WithBias(false)),
 	}
 	m.optimizer = optimizer.NewAdam(m.params(), optimizer.WithAdamLr(lr))
 	return m
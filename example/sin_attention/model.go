@@ -24,7 +24,7 @@ func newModel() *model {
 	}
 	m.flatten = layer.NewFlatten("flatten")
 	m.sigmoid = activation.NewSigmoid()
-	m.outputLayer = layer.NewLinear("output", unitSize, 1, layer.WithDevice(device))
+	m.outputLayer = layer.NewLinear("output", unitSize, 1, layer.WithDevice(device), layer.WithBias(false))
 	m.optimizer = optimizer.NewAdam(m.params(), optimizer.WithAdamLr(lr))
 	return &m
 }
@@ -3,36 +3,38 @@ package main
 import (
 	"github.com/lwch/gotorch/tensor"
 	"github.com/lwch/tnn/nn/layer"
-	"github.com/lwch/tnn/nn/layer/activation"
 )
 
 type transformer struct {
-	attn    *layer.Attention
-	dense   *layer.Linear
-	sigmoid *activation.Sigmoid
-	norm1   *layer.LayerNorm
-	norm2   *layer.LayerNorm
-	output  *layer.Linear
+	attn  *layer.Attention
+	ffn   *layer.FeedForward
+	norm1 *layer.LayerNorm
+	norm2 *layer.LayerNorm
 }
 
 func newTransformer() *transformer {
+	ffn := layer.NewFeedForward("attn.ffn", dims, layer.WithDevice(device), layer.WithBias(false))
+	ffn.SetActivation(layer.FeedForwardSigmoid)
 	return &transformer{
-		attn:    layer.NewAttention("attn", dims, 1, 0.1, false, layer.WithDevice(device)),
-		dense:   layer.NewLinear("attn.l1", dims, dims*4, layer.WithDevice(device)),
-		sigmoid: activation.NewSigmoid(),
-		norm1:   layer.NewLayerNorm("attn.norm1", dims, layer.WithDevice(device)),
-		norm2:   layer.NewLayerNorm("attn.norm2", dims, layer.WithDevice(device)),
-		output:  layer.NewLinear("attn.l2", dims*4, dims, layer.WithDevice(device)),
+		attn:  layer.NewAttention("attn", dims, 1, 0.1, false, layer.WithDevice(device)),
+		ffn:   ffn,
+		norm1: newLayerNorm("attn.norm1"),
+		norm2: newLayerNorm("attn.norm2"),
 	}
 }
 
+// newLayerNorm creates the layer norm without bias and with eps 1e-9 like the old versions
+func newLayerNorm(name string) *layer.LayerNorm {
+	norm := layer.NewLayerNorm(name, dims, layer.WithDevice(device), layer.WithBias(false))
+	norm.SetEps(1e-9)
+	return norm
+}
+
 func (t *transformer) Forward(x *tensor.Tensor, train bool) *tensor.Tensor {
 	y := t.attn.Forward(x, x, x, nil, true, train)
 	y = y.Add(x)
 	selfOut := t.norm1.Forward(y)
-	y = t.dense.Forward(y)
-	y = t.sigmoid.Forward(y)
-	y = t.output.Forward(y)
+	y = t.ffn.Forward(y, train)
 	y = y.Add(selfOut)
 	y = t.norm2.Forward(y)
 	return y
@@ -43,10 +45,7 @@ func (t *transformer) params() []*tensor.Tensor {
 	for _, p := range t.attn.Params() {
 		ret = append(ret, p)
 	}
-	for _, p := range t.dense.Params() {
-		ret = append(ret, p)
-	}
-	for _, p := range t.output.Params() {
+	for _, p := range t.ffn.Params() {
 		ret = append(ret, p)
 	}
 	for _, p := range t.norm1.Params() {
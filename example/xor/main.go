@@ -29,7 +29,7 @@ var lossFunc = loss.NewMse
 
 func main() {
 	if _, err := os.Stat(modelFile); os.IsNotExist(err) {
-		train()
+		firstTrain()
 		return
 	}
 	m := loadModel()
@@ -37,14 +37,11 @@ func main() {
 	predict(m)
 }
 
-func train() {
-	hidden := layer.NewLinear("hidden", 2, hiddenSize, layer.WithDevice(device))
-	outputLayer := layer.NewLinear("output", hiddenSize, 1, layer.WithDevice(device))
+func firstTrain() {
+	hidden := layer.NewLinear("hidden", 2, hiddenSize, layer.WithDevice(device), layer.WithBias(false))
+	outputLayer := layer.NewLinear("output", hiddenSize, 1, layer.WithDevice(device), layer.WithBias(false))
 
-	net := net.New(device)
-	net.Add(hidden)
-	net.Add(activation.NewReLU())
-	net.Add(outputLayer)
+	net := net.NewSequential(device, hidden, activation.NewReLU(), outputLayer)
 	// optimizer := optimizer.NewSGD(lr, 0)
 
 	m := newModel(net)
@@ -56,16 +53,9 @@ func train() {
 
 	var lossPoints plotter.XYs
 	begin := time.Now()
-	for i := 0; i < epoch; i++ {
-		x, y := getBatch()
-		loss := m.Train(x, y)
-		if i%10 == 0 {
-			acc := accuracy(m)
-			fmt.Printf("Epoch: %d, Loss: %e, Accuracy: %.02f%%\n",
-				i, loss, acc)
-			lossPoints = append(lossPoints, plotter.XY{X: float64(i), Y: float64(loss)})
-		}
-	}
+	runtime.Assert(m.Fit(epoch, 10, func(epoch int, loss float64) {
+		lossPoints = append(lossPoints, plotter.XY{X: float64(epoch), Y: loss})
+	}))
 	fmt.Printf("train cost: %s, param count: %d\n",
 		time.Since(begin).String(), net.ParamCount())
 	fmt.Println("predict:")
@@ -86,22 +76,14 @@ func train() {
 }
 
 func loadModel() *model {
-	net := net.New(device)
+	net := net.NewSequential(device)
 	runtime.Assert(net.Load(modelFile))
 
 	return newModel(net)
 }
 
 func nextTrain(m *model) {
-	for i := 0; i < 1000; i++ {
-		x, y := getBatch()
-		loss := m.Train(x, y)
-		if i%100 == 0 {
-			acc := accuracy(m)
-			fmt.Printf("Epoch: %d, Loss: %e, Accuracy: %.02f%%\n",
-				i, loss, acc)
-		}
-	}
+	runtime.Assert(m.Fit(1000, 100, nil))
 }
 
 func predict(m *model) {
@@ -131,6 +113,16 @@ func accuracy(m *model) float32 {
 	return float32(correct) * 100 / 4
 }
 
+type dataset struct{}
+
+func (dataset) Len() int {
+	return 4
+}
+
+func (dataset) Batch([]int) (*tensor.Tensor, *tensor.Tensor) {
+	return getBatch()
+}
+
 func getBatch() (*tensor.Tensor, *tensor.Tensor) {
 	x := tensor.FromFloat32([]float32{
 		0, 0,
@@ -1,46 +1,46 @@
 package main
 
 import (
-	"runtime"
+	"fmt"
 
 	"github.com/lwch/gotorch/optimizer"
 	"github.com/lwch/gotorch/tensor"
-	"github.com/lwch/tnn/nn/layer"
-	"github.com/lwch/tnn/nn/layer/activation"
 	"github.com/lwch/tnn/nn/net"
+	"github.com/lwch/tnn/nn/train"
 )
 
 type model struct {
-	net *net.Net
+	net *net.Sequential
 }
 
-func newModel(net *net.Net) *model {
+func newModel(net *net.Sequential) *model {
 	m := &model{net: net}
 	m.net.SetOptimizer(optimizer.NewAdam(m.net.Params(), optimizer.WithAdamLr(lr)))
 	return m
 }
 
 func (m *model) Forward(x *tensor.Tensor) *tensor.Tensor {
-	output := x
-	for _, l := range m.net.Layers() {
-		switch ln := l.(type) {
-		case *layer.Linear:
-			output = ln.Forward(output)
-		case *activation.ReLU:
-			output = ln.Forward(output)
-		}
-	}
-	return output
+	return m.net.Forward(x, false)
 }
 
-func (m *model) Train(x, y *tensor.Tensor) float32 {
-	pred := m.Forward(x)
-	l := lossFunc(pred, y)
-	l.Backward()
-	value := l.Float32Value()[0]
-	m.net.GetOptimizer().Step()
-	runtime.GC()
-	return value
+// Fit 训练epochs次，每隔every次输出一次loss和准确率
+func (m *model) Fit(epochs, every int, fn func(epoch int, loss float64)) error {
+	trainer := train.New(m.net, func(pred, target *tensor.Tensor) *tensor.Tensor {
+		return lossFunc(pred, target)
+	}, m.net.GetOptimizer(),
+		train.WithEpochs(epochs),
+		train.WithBatchSize(4),
+		train.OnEpochEnd(func(ret train.EpochResult) {
+			if (ret.Epoch-1)%every != 0 {
+				return
+			}
+			fmt.Printf("Epoch: %d, Loss: %e, Accuracy: %.02f%%\n",
+				ret.Epoch-1, ret.Loss, accuracy(m))
+			if fn != nil {
+				fn(ret.Epoch-1, ret.Loss)
+			}
+		}))
+	return trainer.Fit(dataset{})
 }
 
 func (m *model) Predict(x *tensor.Tensor) []float32 {
@@ -0,0 +1,340 @@
+package generate
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+type options struct {
+	maxLength   int
+	temperature float64
+	topK        int
+	topP        float64
+	penalty     float64
+	beams       int
+	stop        map[int64]bool
+	seed        int64
+	device      consts.DeviceType
+}
+
+type Option func(*options)
+
+// WithMaxLength sets the max count of generated tokens, default is 32
+func WithMaxLength(n int) Option {
+	return func(o *options) {
+		o.maxLength = n
+	}
+}
+
+// WithTemperature samples the next token from softmax(logits/t), default is 0 which is greedy
+func WithTemperature(t float64) Option {
+	return func(o *options) {
+		o.temperature = t
+	}
+}
+
+// WithTopK samples from the k most likely tokens only
+func WithTopK(k int) Option {
+	return func(o *options) {
+		o.topK = k
+	}
+}
+
+// WithTopP samples from the smallest set of tokens whose cumulative probability exceeds p (nucleus sampling)
+func WithTopP(p float64) Option {
+	return func(o *options) {
+		o.topP = p
+	}
+}
+
+// WithRepetitionPenalty divides the positive logits and multiplies the negative logits of the tokens
+// already in the sequence by penalty, default is 1 which disables it
+func WithRepetitionPenalty(penalty float64) Option {
+	return func(o *options) {
+		o.penalty = penalty
+	}
+}
+
+// WithBeams runs beam search with n beams when n > 1, sampling options are ignored
+func WithBeams(n int) Option {
+	return func(o *options) {
+		o.beams = n
+	}
+}
+
+// WithStopTokens stops the sequence after any of tokens is generated, the stop token is kept in the output
+func WithStopTokens(tokens ...int64) Option {
+	return func(o *options) {
+		for _, t := range tokens {
+			o.stop[t] = true
+		}
+	}
+}
+
+// WithSeed makes the sampling deterministic
+func WithSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = seed
+	}
+}
+
+// WithDevice sets the device of the tokens passed to the model, default is cpu
+func WithDevice(device consts.DeviceType) Option {
+	return func(o *options) {
+		o.device = device
+	}
+}
+
+type generator struct {
+	options
+	model Model
+	cache CachedModel
+	rand  *rand.Rand
+}
+
+// Generate generates the tokens following prompt, the returned tokens do not include prompt
+func Generate(m Model, prompt []int64, opts ...Option) []int64 {
+	g := generator{
+		options: options{
+			maxLength: 32,
+			penalty:   1,
+			beams:     1,
+			stop:      make(map[int64]bool),
+			seed:      time.Now().UnixNano(),
+			device:    consts.KCPU,
+		},
+		model: m,
+	}
+	for _, opt := range opts {
+		opt(&g.options)
+	}
+	g.rand = rand.New(rand.NewSource(g.seed))
+	if cache, ok := m.(CachedModel); ok {
+		g.cache = cache
+		cache.ResetCache()
+	}
+	if len(prompt) == 0 || g.maxLength <= 0 {
+		return nil
+	}
+	if g.beams > 1 {
+		return g.beamSearch(prompt)
+	}
+	return g.sample(prompt)
+}
+
+// next returns the logits of the next token of each sequence, the sequences must have the same length,
+// seen is the count of tokens already passed to the cached model
+func (g *generator) next(seqs [][]int64, seen int) [][]float32 {
+	input := seqs
+	if g.cache != nil && seen > 0 {
+		input = make([][]int64, len(seqs))
+		for i, seq := range seqs {
+			input[i] = seq[seen:]
+		}
+	}
+	size := int64(len(input[0]))
+	data := make([]int64, 0, int64(len(input))*size)
+	for _, seq := range input {
+		data = append(data, seq...)
+	}
+	tokens := tensor.FromInt64(data,
+		tensor.WithShapes(int64(len(input)), size),
+		tensor.WithDevice(g.device))
+	logits := g.model.Forward(tokens) // (batch, seq, vocab)
+	shapes := logits.Shapes()
+	vocab := shapes[2]
+	values := logits.NArrow(1, shapes[1]-1, 1).
+		Reshape(shapes[0], vocab).
+		ToDevice(consts.KCPU).
+		ToScalarType(consts.KFloat).
+		Float32Value()
+	ret := make([][]float32, shapes[0])
+	for i := range ret {
+		ret[i] = values[int64(i)*vocab : int64(i+1)*vocab]
+		g.applyPenalty(ret[i], seqs[i])
+	}
+	return ret
+}
+
+func (g *generator) applyPenalty(logits []float32, seq []int64) {
+	if g.penalty == 1 {
+		return
+	}
+	seen := make(map[int64]bool, len(seq))
+	for _, t := range seq {
+		if seen[t] || t < 0 || t >= int64(len(logits)) {
+			continue
+		}
+		seen[t] = true
+		if logits[t] > 0 {
+			logits[t] /= float32(g.penalty)
+		} else {
+			logits[t] *= float32(g.penalty)
+		}
+	}
+}
+
+func argmax(logits []float32) int64 {
+	var idx int
+	for i, v := range logits {
+		if v > logits[idx] {
+			idx = i
+		}
+	}
+	return int64(idx)
+}
+
+// logSoftmax returns log(softmax(logits/temperature)) in float64
+func logSoftmax(logits []float32, temperature float64) []float64 {
+	ret := make([]float64, len(logits))
+	max := math.Inf(-1)
+	for i, v := range logits {
+		ret[i] = float64(v) / temperature
+		max = math.Max(max, ret[i])
+	}
+	var sum float64
+	for _, v := range ret {
+		sum += math.Exp(v - max)
+	}
+	lse := max + math.Log(sum)
+	for i := range ret {
+		ret[i] -= lse
+	}
+	return ret
+}
+
+// sortedIndex returns the index of values in descending order
+func sortedIndex(values []float64) []int {
+	idx := make([]int, len(values))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return values[idx[i]] > values[idx[j]]
+	})
+	return idx
+}
+
+// pick chooses the next token by greedy or sampling with top-k and top-p
+func (g *generator) pick(logits []float32) int64 {
+	if g.temperature <= 0 {
+		return argmax(logits)
+	}
+	logProbs := logSoftmax(logits, g.temperature)
+	idx := sortedIndex(logProbs)
+	if g.topK > 0 && g.topK < len(idx) {
+		idx = idx[:g.topK]
+	}
+	probs := make([]float64, len(idx))
+	var sum float64
+	for i, j := range idx {
+		probs[i] = math.Exp(logProbs[j])
+		sum += probs[i]
+		if g.topP > 0 && sum >= g.topP {
+			idx = idx[:i+1]
+			probs = probs[:i+1]
+			break
+		}
+	}
+	r := g.rand.Float64() * sum
+	for i, p := range probs {
+		r -= p
+		if r <= 0 {
+			return int64(idx[i])
+		}
+	}
+	return int64(idx[len(idx)-1])
+}
+
+func (g *generator) sample(prompt []int64) []int64 {
+	seq := append([]int64(nil), prompt...)
+	var seen int
+	for i := 0; i < g.maxLength; i++ {
+		logits := g.next([][]int64{seq}, seen)
+		seen = len(seq)
+		token := g.pick(logits[0])
+		seq = append(seq, token)
+		if g.stop[token] {
+			break
+		}
+	}
+	return seq[len(prompt):]
+}
+
+type beam struct {
+	seq   []int64
+	score float64 // sum of log probability
+}
+
+// normalized score by the count of generated tokens
+func (b beam) normalized(prompt int) float64 {
+	return b.score / float64(len(b.seq)-prompt)
+}
+
+func (g *generator) beamSearch(prompt []int64) []int64 {
+	alive := []beam{{seq: append([]int64(nil), prompt...)}}
+	var done []beam
+	var seen int
+	for step := 0; step < g.maxLength && len(alive) > 0; step++ {
+		seqs := make([][]int64, len(alive))
+		for i, b := range alive {
+			seqs[i] = b.seq
+		}
+		logits := g.next(seqs, seen)
+		seen = len(alive[0].seq)
+
+		type candidate struct {
+			parent int
+			token  int64
+			score  float64
+		}
+		var candidates []candidate
+		for i, b := range alive {
+			logProbs := logSoftmax(logits[i], 1)
+			idx := sortedIndex(logProbs)
+			if len(idx) > g.beams {
+				idx = idx[:g.beams]
+			}
+			for _, j := range idx {
+				candidates = append(candidates, candidate{i, int64(j), b.score + logProbs[j]})
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].score > candidates[j].score
+		})
+
+		var next []beam
+		var parents []int64
+		for _, c := range candidates {
+			if len(next)+len(done) >= g.beams {
+				break
+			}
+			seq := make([]int64, len(alive[c.parent].seq), len(alive[c.parent].seq)+1)
+			copy(seq, alive[c.parent].seq)
+			b := beam{seq: append(seq, c.token), score: c.score}
+			if g.stop[c.token] {
+				done = append(done, b)
+				continue
+			}
+			next = append(next, b)
+			parents = append(parents, int64(c.parent))
+		}
+		if g.cache != nil && len(next) > 0 {
+			g.cache.SelectCache(parents)
+		}
+		alive = next
+	}
+	done = append(done, alive...)
+	best := done[0]
+	for _, b := range done[1:] {
+		if b.normalized(len(prompt)) > best.normalized(len(prompt)) {
+			best = b
+		}
+	}
+	return best.seq[len(prompt):]
+}
@@ -0,0 +1,120 @@
+package generate
+
+import (
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+// bigram predicts the next token only by the last token, next[i] is the most likely token after i
+type bigram struct {
+	next  []int64
+	calls [][]int64
+	cache [][]int64
+}
+
+const vocab = 5
+
+func (m *bigram) Forward(tokens *tensor.Tensor) *tensor.Tensor {
+	shapes := tokens.Shapes()
+	values := tokens.Int64Value()
+	m.calls = append(m.calls, values)
+	logits := make([]float32, 0, shapes[0]*shapes[1]*vocab)
+	for _, t := range values {
+		for i := 0; i < vocab; i++ {
+			if int64(i) == m.next[t] {
+				logits = append(logits, 2)
+			} else {
+				logits = append(logits, 1)
+			}
+		}
+	}
+	return tensor.FromFloat32(logits, tensor.WithShapes(shapes[0], shapes[1], vocab))
+}
+
+type cachedBigram struct {
+	bigram
+	selects [][]int64
+}
+
+func (m *cachedBigram) ResetCache() {
+	m.calls = nil
+}
+
+func (m *cachedBigram) SelectCache(idx []int64) {
+	m.selects = append(m.selects, idx)
+}
+
+func equal(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGreedy(t *testing.T) {
+	m := &bigram{next: []int64{1, 2, 3, 4, 0}}
+	got := Generate(m, []int64{0}, WithMaxLength(6), WithStopTokens(4))
+	if !equal(got, []int64{1, 2, 3, 4}) {
+		t.Fatalf("unexpected tokens: %v", got)
+	}
+	if len(m.calls) != 4 || len(m.calls[3]) != 4 {
+		t.Fatalf("model should get the whole sequence without cache: %v", m.calls)
+	}
+}
+
+func TestCached(t *testing.T) {
+	m := &cachedBigram{bigram: bigram{next: []int64{1, 2, 3, 4, 0}}}
+	got := Generate(m, []int64{3, 0}, WithMaxLength(3))
+	if !equal(got, []int64{1, 2, 3}) {
+		t.Fatalf("unexpected tokens: %v", got)
+	}
+	want := [][]int64{{3, 0}, {1}, {2}}
+	for i, call := range m.calls {
+		if !equal(call, want[i]) {
+			t.Fatalf("unexpected input of call %d: %v", i, call)
+		}
+	}
+}
+
+func TestSeed(t *testing.T) {
+	m := &bigram{next: []int64{1, 2, 3, 4, 0}}
+	a := Generate(m, []int64{0}, WithTemperature(10), WithSeed(42), WithDevice(consts.KCPU))
+	b := Generate(m, []int64{0}, WithTemperature(10), WithSeed(42))
+	if !equal(a, b) {
+		t.Fatalf("same seed got different tokens: %v, %v", a, b)
+	}
+}
+
+func TestTopK(t *testing.T) {
+	m := &bigram{next: []int64{1, 2, 3, 4, 0}}
+	got := Generate(m, []int64{0}, WithMaxLength(8), WithTemperature(100), WithTopK(1), WithSeed(1))
+	if !equal(got, []int64{1, 2, 3, 4, 0, 1, 2, 3}) {
+		t.Fatalf("top-k 1 should be greedy: %v", got)
+	}
+}
+
+func TestRepetitionPenalty(t *testing.T) {
+	m := &bigram{next: []int64{0, 0, 0, 0, 0}}
+	got := Generate(m, []int64{0}, WithMaxLength(1), WithRepetitionPenalty(1e9))
+	if got[0] == 0 {
+		t.Fatalf("repeated token should be penalized: %v", got)
+	}
+}
+
+func TestBeamSearch(t *testing.T) {
+	m := &cachedBigram{bigram: bigram{next: []int64{1, 2, 3, 4, 0}}}
+	got := Generate(m, []int64{0}, WithMaxLength(4), WithBeams(3), WithStopTokens(3))
+	if !equal(got, []int64{1, 2, 3}) {
+		t.Fatalf("unexpected tokens: %v", got)
+	}
+	if len(m.selects) == 0 || len(m.selects[0]) != 3 {
+		t.Fatalf("cache should follow the beams: %v", m.selects)
+	}
+}
@@ -0,0 +1,22 @@
+package generate
+
+import (
+	"github.com/lwch/gotorch/tensor"
+)
+
+// Model is a causal language model
+type Model interface {
+	// Forward returns the logits (batch, seq, vocab) of tokens (batch, seq) in int64,
+	// the logits of the last token predict the next token
+	Forward(tokens *tensor.Tensor) *tensor.Tensor
+}
+
+// CachedModel keeps the keys and values of the tokens it has seen, e.g. by layer.KVCache,
+// Forward gets the whole prompt on the first call and only the new token of each sequence after it
+type CachedModel interface {
+	Model
+	// ResetCache is called before each generation
+	ResetCache()
+	// SelectCache keeps the cache of the sequences in idx order, see layer.KVCache.Select
+	SelectCache(idx []int64)
+}
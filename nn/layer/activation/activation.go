@@ -35,6 +35,16 @@ func (layer *base) Params() []*tensor.Tensor {
 	return nil
 }
 
+func (layer *base) ParamNames() []string {
+	return nil
+}
+
+func (*base) SetParams(params []*tensor.Tensor) {
+	if len(params) > 0 {
+		panic("activation have no params")
+	}
+}
+
 func (*base) Args() map[string]float32 {
 	return nil
 }
@@ -54,3 +64,15 @@ func (*base) ToScalarType(t consts.ScalarType) {
 func (*base) Reset() {
 	// activation have no params
 }
+
+// scalar returns v in shape (1) with the scalar type and device of x
+func scalar(x *tensor.Tensor, v float64) *tensor.Tensor {
+	return tensor.FromFloat64([]float64{v},
+		tensor.WithShapes(1),
+		tensor.WithDevice(x.DeviceType())).ToScalarType(x.ScalarType())
+}
+
+// negPart returns min(x, 0)
+func negPart(x *tensor.Tensor) *tensor.Tensor {
+	return x.Neg().Relu().Neg()
+}
@@ -0,0 +1,71 @@
+package activation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/nn/layer"
+)
+
+func TestActivations(t *testing.T) {
+	input := []float32{-30, -2, -0.5, 0, 0.5, 2, 30}
+	softplus := func(x float64) float64 {
+		return math.Max(x, 0) + math.Log1p(math.Exp(-math.Abs(x)))
+	}
+	for _, c := range []struct {
+		layer interface {
+			layer.Layer
+			Forward(*tensor.Tensor) *tensor.Tensor
+		}
+		fn func(x float64) float64
+	}{
+		{NewLeakyReLU(0.1), func(x float64) float64 {
+			return math.Max(x, 0.1*x)
+		}},
+		{NewELU(0.5), func(x float64) float64 {
+			if x > 0 {
+				return x
+			}
+			return 0.5 * (math.Exp(x) - 1)
+		}},
+		{NewSELU(), func(x float64) float64 {
+			if x > 0 {
+				return seluScale * x
+			}
+			return seluScale * seluAlpha * (math.Exp(x) - 1)
+		}},
+		{NewSiLU(), func(x float64) float64 {
+			return x / (1 + math.Exp(-x))
+		}},
+		{NewMish(), func(x float64) float64 {
+			return x * math.Tanh(softplus(x))
+		}},
+		{NewSoftplus(2), func(x float64) float64 {
+			return softplus(2*x) / 2
+		}},
+		{NewHardswish(), func(x float64) float64 {
+			return x * math.Min(math.Max(x+3, 0), 6) / 6
+		}},
+	} {
+		x := tensor.FromFloat32(input, tensor.WithShapes(int64(len(input))))
+		y := c.layer.Forward(x).Float32Value()
+		for i, v := range input {
+			if want := c.fn(float64(v)); math.Abs(float64(y[i])-want) > 1e-4 {
+				t.Fatalf("%s: unexpected value of %f: %f != %f", c.layer.Class(), v, y[i], want)
+			}
+		}
+	}
+}
+
+func TestLogSoftmax(t *testing.T) {
+	x := tensor.FromFloat32([]float32{1, 2, 3, 1000, 1001, 1002}, tensor.WithShapes(2, 3))
+	softmax := NewSoftmax(-1).Forward(x).Float32Value()
+	logSoftmax := LoadLogSoftmax("log_softmax", nil, NewLogSoftmax(-1).Args()).(*LogSoftmax).
+		Forward(x).Float32Value()
+	for i := range softmax {
+		if math.Abs(math.Log(float64(softmax[i]))-float64(logSoftmax[i])) > 1e-4 {
+			t.Fatalf("unexpected value %d: %f != log(%f)", i, logSoftmax[i], softmax[i])
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package activation
+
+import (
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/nn/layer"
+)
+
+type ELU struct {
+	*base
+	alpha float64
+}
+
+func NewELU(alpha float64) *ELU {
+	var layer ELU
+	layer.base = new("elu")
+	layer.alpha = alpha
+	return &layer
+}
+
+func LoadElu(name string, _ []*tensor.Tensor, args map[string]float32) layer.Layer {
+	var layer ELU
+	layer.base = new("elu")
+	layer.name = name
+	layer.alpha = float64(args["alpha"])
+	return &layer
+}
+
+func (layer *ELU) Forward(x *tensor.Tensor) *tensor.Tensor {
+	return elu(x, layer.alpha)
+}
+
+func (layer *ELU) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
+func (layer *ELU) Args() map[string]float32 {
+	return map[string]float32{
+		"alpha": float32(layer.alpha),
+	}
+}
+
+// elu computes max(x, 0) + alpha * (exp(min(x, 0)) - 1),
+// exp is only applied to the negative part to avoid overflow
+func elu(x *tensor.Tensor, alpha float64) *tensor.Tensor {
+	neg := negPart(x).Exp().Sub(scalar(x, 1)).Mul(scalar(x, alpha))
+	return x.Relu().Add(neg)
+}
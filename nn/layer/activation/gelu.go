@@ -31,6 +31,10 @@ func (layer *GeLU) Forward(x *tensor.Tensor) *tensor.Tensor {
 	return x.Gelu(layer.tanh)
 }
 
+func (layer *GeLU) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
 func (layer *GeLU) Args() map[string]float32 {
 	var tanh float32
 	if layer.tanh {
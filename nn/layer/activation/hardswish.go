@@ -0,0 +1,35 @@
+package activation
+
+import (
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/nn/layer"
+)
+
+// Hardswish computes x * relu6(x + 3) / 6
+type Hardswish struct {
+	*base
+}
+
+func NewHardswish() *Hardswish {
+	var layer Hardswish
+	layer.base = new("hardswish")
+	return &layer
+}
+
+func LoadHardswish(name string, _ []*tensor.Tensor, _ map[string]float32) layer.Layer {
+	var layer Hardswish
+	layer.base = new("hardswish")
+	layer.name = name
+	return &layer
+}
+
+func (layer *Hardswish) Forward(x *tensor.Tensor) *tensor.Tensor {
+	y := x.Add(scalar(x, 3))
+	// relu6(y) = relu(y) - relu(y - 6)
+	relu6 := y.Relu().Sub(y.Sub(scalar(x, 6)).Relu())
+	return x.Mul(relu6).Div(scalar(x, 6))
+}
+
+func (layer *Hardswish) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
@@ -0,0 +1,40 @@
+package activation
+
+import (
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/nn/layer"
+)
+
+type LeakyReLU struct {
+	*base
+	negSlope float64
+}
+
+func NewLeakyReLU(negSlope float64) *LeakyReLU {
+	var layer LeakyReLU
+	layer.base = new("leaky_relu")
+	layer.negSlope = negSlope
+	return &layer
+}
+
+func LoadLeakyRelu(name string, _ []*tensor.Tensor, args map[string]float32) layer.Layer {
+	var layer LeakyReLU
+	layer.base = new("leaky_relu")
+	layer.name = name
+	layer.negSlope = float64(args["negative_slope"])
+	return &layer
+}
+
+func (layer *LeakyReLU) Forward(x *tensor.Tensor) *tensor.Tensor {
+	return x.LeakyRelu(layer.negSlope)
+}
+
+func (layer *LeakyReLU) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
+func (layer *LeakyReLU) Args() map[string]float32 {
+	return map[string]float32{
+		"negative_slope": float32(layer.negSlope),
+	}
+}
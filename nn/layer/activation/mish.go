@@ -0,0 +1,32 @@
+package activation
+
+import (
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/nn/layer"
+)
+
+// Mish computes x * tanh(softplus(x))
+type Mish struct {
+	*base
+}
+
+func NewMish() *Mish {
+	var layer Mish
+	layer.base = new("mish")
+	return &layer
+}
+
+func LoadMish(name string, _ []*tensor.Tensor, _ map[string]float32) layer.Layer {
+	var layer Mish
+	layer.base = new("mish")
+	layer.name = name
+	return &layer
+}
+
+func (layer *Mish) Forward(x *tensor.Tensor) *tensor.Tensor {
+	return x.Mul(softplus(x).Tanh())
+}
+
+func (layer *Mish) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
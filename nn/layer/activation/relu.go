@@ -25,3 +25,7 @@ func LoadRelu(name string, _ []*tensor.Tensor, _ map[string]float32) layer.Layer
 func (layer *ReLU) Forward(x *tensor.Tensor) *tensor.Tensor {
 	return x.Relu()
 }
+
+func (layer *ReLU) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
@@ -0,0 +1,36 @@
+package activation
+
+import (
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/nn/layer"
+)
+
+const (
+	seluAlpha = 1.6732632423543772848170429916717
+	seluScale = 1.0507009873554804934193349852946
+)
+
+type SELU struct {
+	*base
+}
+
+func NewSELU() *SELU {
+	var layer SELU
+	layer.base = new("selu")
+	return &layer
+}
+
+func LoadSelu(name string, _ []*tensor.Tensor, _ map[string]float32) layer.Layer {
+	var layer SELU
+	layer.base = new("selu")
+	layer.name = name
+	return &layer
+}
+
+func (layer *SELU) Forward(x *tensor.Tensor) *tensor.Tensor {
+	return elu(x, seluAlpha).Mul(scalar(x, seluScale))
+}
+
+func (layer *SELU) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
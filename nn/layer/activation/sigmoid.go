@@ -25,3 +25,7 @@ func LoadSigmoid(name string, _ []*tensor.Tensor, _ map[string]float32) layer.La
 func (layer *Sigmoid) Forward(x *tensor.Tensor) *tensor.Tensor {
 	return x.Sigmoid()
 }
+
+func (layer *Sigmoid) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
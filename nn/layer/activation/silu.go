@@ -0,0 +1,32 @@
+package activation
+
+import (
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/nn/layer"
+)
+
+// SiLU computes x * sigmoid(x), also known as Swish
+type SiLU struct {
+	*base
+}
+
+func NewSiLU() *SiLU {
+	var layer SiLU
+	layer.base = new("silu")
+	return &layer
+}
+
+func LoadSilu(name string, _ []*tensor.Tensor, _ map[string]float32) layer.Layer {
+	var layer SiLU
+	layer.base = new("silu")
+	layer.name = name
+	return &layer
+}
+
+func (layer *SiLU) Forward(x *tensor.Tensor) *tensor.Tensor {
+	return x.Silu()
+}
+
+func (layer *SiLU) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
@@ -0,0 +1,76 @@
+package activation
+
+import (
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/nn/layer"
+)
+
+type Softmax struct {
+	*base
+	dim int64
+}
+
+func NewSoftmax(dim int64) *Softmax {
+	var layer Softmax
+	layer.base = new("softmax")
+	layer.dim = dim
+	return &layer
+}
+
+func LoadSoftmax(name string, _ []*tensor.Tensor, args map[string]float32) layer.Layer {
+	var layer Softmax
+	layer.base = new("softmax")
+	layer.name = name
+	layer.dim = int64(args["dim"])
+	return &layer
+}
+
+func (layer *Softmax) Forward(x *tensor.Tensor) *tensor.Tensor {
+	return x.Softmax(layer.dim)
+}
+
+func (layer *Softmax) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
+func (layer *Softmax) Args() map[string]float32 {
+	return map[string]float32{
+		"dim": float32(layer.dim),
+	}
+}
+
+type LogSoftmax struct {
+	*base
+	dim int64
+}
+
+func NewLogSoftmax(dim int64) *LogSoftmax {
+	var layer LogSoftmax
+	layer.base = new("log_softmax")
+	layer.dim = dim
+	return &layer
+}
+
+func LoadLogSoftmax(name string, _ []*tensor.Tensor, args map[string]float32) layer.Layer {
+	var layer LogSoftmax
+	layer.base = new("log_softmax")
+	layer.name = name
+	layer.dim = int64(args["dim"])
+	return &layer
+}
+
+// Forward computes x - max - log(sum(exp(x - max))) which is stabler than log(softmax(x))
+func (layer *LogSoftmax) Forward(x *tensor.Tensor) *tensor.Tensor {
+	x = x.Sub(x.Max(layer.dim, true))
+	return x.Sub(x.Exp().Sum(layer.dim, true).Log())
+}
+
+func (layer *LogSoftmax) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
+func (layer *LogSoftmax) Args() map[string]float32 {
+	return map[string]float32{
+		"dim": float32(layer.dim),
+	}
+}
@@ -0,0 +1,48 @@
+package activation
+
+import (
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/nn/layer"
+)
+
+type Softplus struct {
+	*base
+	beta float64
+}
+
+func NewSoftplus(beta float64) *Softplus {
+	var layer Softplus
+	layer.base = new("softplus")
+	layer.beta = beta
+	return &layer
+}
+
+func LoadSoftplus(name string, _ []*tensor.Tensor, args map[string]float32) layer.Layer {
+	var layer Softplus
+	layer.base = new("softplus")
+	layer.name = name
+	layer.beta = float64(args["beta"])
+	return &layer
+}
+
+func (layer *Softplus) Forward(x *tensor.Tensor) *tensor.Tensor {
+	if layer.beta == 1 {
+		return softplus(x)
+	}
+	return softplus(x.Mul(scalar(x, layer.beta))).Div(scalar(x, layer.beta))
+}
+
+func (layer *Softplus) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
+func (layer *Softplus) Args() map[string]float32 {
+	return map[string]float32{
+		"beta": float32(layer.beta),
+	}
+}
+
+// softplus computes log(1 + exp(x)) as max(x, 0) + log(1 + exp(-|x|)) to avoid overflow
+func softplus(x *tensor.Tensor) *tensor.Tensor {
+	return x.Relu().Add(x.Abs().Neg().Exp().Add(scalar(x, 1)).Log())
+}
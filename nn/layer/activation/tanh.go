@@ -25,3 +25,7 @@ func LoadTanh(name string, _ []*tensor.Tensor, _ map[string]float32) layer.Layer
 func (layer *Tanh) Forward(x *tensor.Tensor) *tensor.Tensor {
 	return x.Tanh()
 }
+
+func (layer *Tanh) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
@@ -0,0 +1,137 @@
+package layer
+
+import (
+	"fmt"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+// adaptivePooling pools the last len(output) dims of the input to output, the window of
+// output i on a dim of size n is [floor(i*n/out), ceil((i+1)*n/out)) as in PyTorch
+type adaptivePooling struct {
+	base
+	output []int64
+	max    bool
+}
+
+func (p *adaptivePooling) init(class, name string, output []int64, max bool) {
+	p.new(class, name)
+	p.output = output
+	p.max = max
+}
+
+func (p *adaptivePooling) load(class, name string, dims int, args map[string]float32, max bool) {
+	output := make([]int64, dims)
+	for i := range output {
+		output[i] = int64(args[fmt.Sprintf("output_%d", i)])
+	}
+	p.init(class, name, output, max)
+}
+
+func (p *adaptivePooling) Forward(x *tensor.Tensor) *tensor.Tensor {
+	first := x.Dims() - int64(len(p.output))
+	for i, out := range p.output {
+		dim := first + int64(i)
+		size := x.Shapes()[dim]
+		list := make([]*tensor.Tensor, out)
+		for j := int64(0); j < out; j++ {
+			begin := j * size / out
+			end := ((j+1)*size + out - 1) / out
+			window := x.NArrow(dim, begin, end-begin)
+			if p.max {
+				list[j] = window.Max(dim, true)
+			} else {
+				list[j] = window.Mean(dim, true)
+			}
+		}
+		x = tensor.Cat(list, int(dim))
+	}
+	return x
+}
+
+func (p *adaptivePooling) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return p.Forward(x)
+}
+
+func (p *adaptivePooling) Args() map[string]float32 {
+	ret := make(map[string]float32, len(p.output))
+	for i, out := range p.output {
+		ret[fmt.Sprintf("output_%d", i)] = float32(out)
+	}
+	return ret
+}
+
+func (p *adaptivePooling) ToScalarType(t consts.ScalarType) {
+}
+
+func (p *adaptivePooling) Reset() {
+}
+
+// AdaptiveAvgPool1D averages x (..., length) to (..., output)
+type AdaptiveAvgPool1D struct {
+	adaptivePooling
+}
+
+func NewAdaptiveAvgPool1D(name string, output int) *AdaptiveAvgPool1D {
+	var layer AdaptiveAvgPool1D
+	layer.init("adaptive_avgpool1d", name, []int64{int64(output)}, false)
+	return &layer
+}
+
+func LoadAdaptiveAvgPool1D(name string, _ []*tensor.Tensor, args map[string]float32) Layer {
+	var layer AdaptiveAvgPool1D
+	layer.load("adaptive_avgpool1d", name, 1, args, false)
+	return &layer
+}
+
+// AdaptiveMaxPool1D takes the max of x (..., length) to (..., output)
+type AdaptiveMaxPool1D struct {
+	adaptivePooling
+}
+
+func NewAdaptiveMaxPool1D(name string, output int) *AdaptiveMaxPool1D {
+	var layer AdaptiveMaxPool1D
+	layer.init("adaptive_maxpool1d", name, []int64{int64(output)}, true)
+	return &layer
+}
+
+func LoadAdaptiveMaxPool1D(name string, _ []*tensor.Tensor, args map[string]float32) Layer {
+	var layer AdaptiveMaxPool1D
+	layer.load("adaptive_maxpool1d", name, 1, args, true)
+	return &layer
+}
+
+// AdaptiveAvgPool2D averages x (..., height, width) to (..., h, w)
+type AdaptiveAvgPool2D struct {
+	adaptivePooling
+}
+
+func NewAdaptiveAvgPool2D(name string, h, w int) *AdaptiveAvgPool2D {
+	var layer AdaptiveAvgPool2D
+	layer.init("adaptive_avgpool2d", name, []int64{int64(h), int64(w)}, false)
+	return &layer
+}
+
+func LoadAdaptiveAvgPool2D(name string, _ []*tensor.Tensor, args map[string]float32) Layer {
+	var layer AdaptiveAvgPool2D
+	layer.load("adaptive_avgpool2d", name, 2, args, false)
+	return &layer
+}
+
+// AdaptiveMaxPool2D takes the max of x (..., height, width) to (..., h, w)
+type AdaptiveMaxPool2D struct {
+	adaptivePooling
+}
+
+func NewAdaptiveMaxPool2D(name string, h, w int) *AdaptiveMaxPool2D {
+	var layer AdaptiveMaxPool2D
+	layer.init("adaptive_maxpool2d", name, []int64{int64(h), int64(w)}, true)
+	return &layer
+}
+
+func LoadAdaptiveMaxPool2D(name string, _ []*tensor.Tensor, args map[string]float32) Layer {
+	var layer AdaptiveMaxPool2D
+	layer.load("adaptive_maxpool2d", name, 2, args, true)
+	return &layer
+}
@@ -10,12 +10,19 @@ import (
 type Attention struct {
 	base
 	dims, heads int
+	kvHeads     int
 	dropout     float64
 	rope        bool
 	ropeBase    int64
+	ropeScaling ropeScaling
+	alibi       bool
+	window      int
 	// params
-	q, k, v *tensor.Tensor
-	scale   *tensor.Tensor
+	q, k, v    *tensor.Tensor
+	o          *tensor.Tensor // optional output projection
+	bq, bk, bv *tensor.Tensor // optional biases
+	bo         *tensor.Tensor
+	scale      *tensor.Tensor
 	// runtime
 	freqs *tensor.Tensor
 }
@@ -25,6 +32,7 @@ func NewAttention(name string, dims, heads int, dropout float64, rope bool, opts
 	layer.new("attention", name, opts...)
 	layer.dims = dims
 	layer.heads = heads
+	layer.kvHeads = heads
 	layer.dropout = dropout
 	layer.rope = rope
 	layer.ropeBase = 10000
@@ -43,66 +51,204 @@ func LoadAttention(name string, params []*tensor.Tensor, args map[string]float32
 	layer.new("attention", name)
 	layer.dims = int(args["dims"])
 	layer.heads = int(args["heads"])
+	layer.kvHeads = layer.heads
+	if n, ok := args["num_kv_heads"]; ok {
+		layer.kvHeads = int(n)
+	}
 	layer.dropout = float64(args["dropout"])
 	layer.rope = args["rope"] != 0
 	layer.ropeBase = int64(args["rope_base"])
 	if layer.ropeBase <= 0 {
 		layer.ropeBase = 10000
 	}
+	layer.ropeScaling = ropeScaling{
+		kind:     ROPEScaling(args["rope_scaling"]),
+		factor:   float64(args["rope_factor"]),
+		original: int64(args["rope_original_length"]),
+	}
+	layer.alibi = args["alibi"] != 0
+	layer.window = int(args["window"])
 	layer.q = params[0]
 	layer.k = params[1]
 	layer.v = params[2]
+	params = params[3:]
+	if args["out_proj"] != 0 {
+		layer.o = params[0]
+		params = params[1:]
+	}
+	if args["qkv_bias"] != 0 {
+		layer.bq = params[0]
+		layer.bk = params[1]
+		layer.bv = params[2]
+		if layer.o != nil {
+			layer.bo = params[3]
+		}
+	}
 	layer.scale = layer.initN(math.Sqrt(float64(layer.dims)))
 	return &layer
 }
 
 func (layer *Attention) SetROPEBase(n int64) {
 	layer.ropeBase = n
+	layer.freqs = nil
 }
 
-func (layer *Attention) Forward(q, k, v, mask *tensor.Tensor, isCausal, train bool) *tensor.Tensor {
-	if mask != nil && isCausal {
-		panic("unexpected mask")
-	}
-	inputShape := q.Shapes()
-	q = q.MatMul(layer.q.Transpose(0, 1)) // (batch, seq, dims)
-	k = k.MatMul(layer.k.Transpose(0, 1)) // (batch, seq, dims)
-	v = v.MatMul(layer.v.Transpose(0, 1)) // (batch, seq, dims)
-	q = layer.split(q)                    // (batch, seq, heads, dims/heads)
-	k = layer.split(k)                    // (batch, seq, heads, dims/heads)
-	v = layer.split(v)                    // (batch, seq, heads, dims/heads)
+// SetROPEScaling extends the context length of RoPE by factor, originalLength is the
+// context length of training which is required by ROPEScalingYaRN
+func (layer *Attention) SetROPEScaling(kind ROPEScaling, factor float64, originalLength int64) {
+	if kind != ROPEScalingNone && factor <= 0 {
+		panic("rope scaling factor must be positive")
+	}
+	if kind == ROPEScalingYaRN && originalLength <= 0 {
+		panic("yarn requires the original context length")
+	}
+	layer.ropeScaling = ropeScaling{
+		kind:     kind,
+		factor:   factor,
+		original: originalLength,
+	}
+	layer.freqs = nil
+}
+
+// SetALiBi adds the ALiBi bias to the score, the bias of head h is -m_h*|i-j| for the query i and key j
+func (layer *Attention) SetALiBi(alibi bool) {
+	layer.alibi = alibi
+}
+
+// SetWindow makes the query i only attend to the keys j with |i-j| < n, 0 disables it
+func (layer *Attention) SetWindow(n int) {
+	layer.window = n
+}
+
+// SetKVHeads reallocates k and v with n heads shared by heads/n query heads,
+// n = 1 is multi-query attention and n = heads is the default multi-head attention
+func (layer *Attention) SetKVHeads(n int) {
+	if n <= 0 || layer.heads%n != 0 {
+		panic("heads must be divisible by kv heads")
+	}
+	layer.kvHeads = n
+	kvDims := int64(layer.dims / layer.heads * n)
+	layer.k = layer.initW(kvDims, int64(layer.dims))
+	layer.v = layer.initW(kvDims, int64(layer.dims))
+	if layer.bk != nil {
+		layer.bk = layer.zeros(kvDims)
+		layer.bv = layer.zeros(kvDims)
+	}
+}
+
+// SetOutputProjection adds a (dims, dims) projection to the concatenated heads when out is true,
+// it has a bias when the QKV biases are enabled
+func (layer *Attention) SetOutputProjection(out bool) {
+	if !out {
+		layer.o = nil
+		layer.bo = nil
+		return
+	}
+	if layer.o == nil {
+		layer.o = layer.initW(int64(layer.dims), int64(layer.dims))
+	}
+	if layer.bq != nil && layer.bo == nil {
+		layer.bo = layer.zeros(int64(layer.dims))
+	}
+}
+
+// SetQKVBias adds the biases to the q, k, v projections and the output projection when bias is true
+func (layer *Attention) SetQKVBias(bias bool) {
+	if !bias {
+		layer.bq, layer.bk, layer.bv, layer.bo = nil, nil, nil, nil
+		return
+	}
+	if layer.bq == nil {
+		layer.bq = layer.zeros(layer.q.Shapes()[0])
+		layer.bk = layer.zeros(layer.k.Shapes()[0])
+		layer.bv = layer.zeros(layer.v.Shapes()[0])
+	}
+	if layer.o != nil && layer.bo == nil {
+		layer.bo = layer.zeros(int64(layer.dims))
+	}
+}
+
+// project returns q (batch, heads, seq, dims/heads), k and v (batch, kvHeads, seq, dims/heads),
+// the RoPE positions of q and k begin at offset
+func (layer *Attention) project(q, k, v *tensor.Tensor, offset int64) (*tensor.Tensor, *tensor.Tensor, *tensor.Tensor) {
+	q = linear(q, layer.q, layer.bq)  // (batch, seq, dims)
+	k = linear(k, layer.k, layer.bk)  // (batch, seq, kvDims)
+	v = linear(v, layer.v, layer.bv)  // (batch, seq, kvDims)
+	q = layer.split(q, layer.heads)   // (batch, seq, heads, dims/heads)
+	k = layer.split(k, layer.kvHeads) // (batch, seq, kvHeads, dims/heads)
+	v = layer.split(v, layer.kvHeads) // (batch, seq, kvHeads, dims/heads)
 	if layer.rope {
-		q, k = layer.applyROPE(q, k, q.Shapes()[1])
+		q, k = layer.applyROPE(q, k, offset)
 	}
 	q = q.Transpose(1, 2) // (batch, heads, seq, dims/heads)
-	k = k.Transpose(1, 2) // (batch, heads, seq, dims/heads)
-	v = v.Transpose(1, 2) // (batch, heads, seq, dims/heads)
+	k = k.Transpose(1, 2) // (batch, kvHeads, seq, dims/heads)
+	v = v.Transpose(1, 2) // (batch, kvHeads, seq, dims/heads)
+	return q, k, v
+}
+
+func (layer *Attention) attend(q, k, v, mask *tensor.Tensor, isCausal, train bool) *tensor.Tensor {
+	seq := q.Shapes()[2]
 	dropout := layer.dropout
 	if !train {
 		dropout = 0
 	}
 	y := tensor.ScaledDotProductAttention(q, k, v, mask, dropout, isCausal) // (batch, heads, seq, dims/heads)
 	y = y.Transpose(1, 2)                                                   // (batch, seq, heads, dims/heads)
-	y = y.Reshape(-1, inputShape[1], int64(layer.dims))                     // (batch, seq, dims)
+	y = y.Reshape(-1, seq, int64(layer.dims))                               // (batch, seq, dims)
+	if layer.o != nil {
+		y = linear(y, layer.o, layer.bo) // (batch, seq, dims)
+	}
 	return y
 }
 
-func (layer *Attention) Score(q, k, v, mask *tensor.Tensor, isCausal, train bool) *tensor.Tensor {
+// Forward runs the attention of q to k and v, mask is added to the score (batch, heads, l, s)
+// and can be nil, e.g. PaddingMask, the causal mask is added to it when isCausal is true
+func (layer *Attention) Forward(q, k, v, mask *tensor.Tensor, isCausal, train bool) *tensor.Tensor {
+	q, k, v = layer.project(q, k, v, 0)
+	k, v = layer.repeat(k), layer.repeat(v)
+	mask = addMask(mask, buildPositionBias(q, k, layer.alibi, layer.window, 0, layer.device))
+	// the causal mask must be built explicitly when passing a mask
 	if mask != nil && isCausal {
-		panic("unexpected mask")
+		mask = addMask(mask, buildCausal(q, k, 0, layer.device))
+		isCausal = false
 	}
-	q = q.MatMul(layer.q.Transpose(0, 1)) // (batch, seq, dims)
-	k = k.MatMul(layer.k.Transpose(0, 1)) // (batch, seq, dims)
-	q = layer.split(q)                    // (batch, seq, heads, dims/heads)
-	k = layer.split(k)                    // (batch, seq, heads, dims/heads)
-	if layer.rope {
-		q, k = layer.applyROPE(q, k, q.Shapes()[1])
+	return layer.attend(q, k, v, mask, isCausal, train)
+}
+
+// ForwardCache runs the causal self attention of x (batch, seq, dims) which are the tokens
+// following the ones in cache, the keys and values of x are appended to cache and the RoPE
+// positions of x begin at cache.Len(). mask is added to the score (batch, heads, seq, cache.Len())
+// after appending and can be nil
+func (layer *Attention) ForwardCache(x, mask *tensor.Tensor, cache *KVCache, train bool) *tensor.Tensor {
+	offset := cache.Len()
+	q, k, v := layer.project(x, x, x, offset)
+	k, v = cache.append(k, v)
+	k, v = layer.repeat(k), layer.repeat(v)
+	mask = addMask(cacheMask(q, k, mask, offset, layer.device),
+		buildPositionBias(q, k, layer.alibi, layer.window, offset, layer.device))
+	return layer.attend(q, k, v, mask, false, train)
+}
+
+func (layer *Attention) Call(x *tensor.Tensor, train bool) *tensor.Tensor {
+	return layer.Forward(x, x, x, nil, false, train)
+}
+
+// CallMulti inputs are q, k, v and an optional mask
+func (layer *Attention) CallMulti(train bool, xs ...*tensor.Tensor) []*tensor.Tensor {
+	var mask *tensor.Tensor
+	if len(xs) > 3 {
+		mask = xs[3]
 	}
-	q = q.Transpose(1, 2) // (batch, heads, seq, dims/heads)
-	k = k.Transpose(1, 2) // (batch, heads, seq, dims/heads)
+	return []*tensor.Tensor{layer.Forward(xs[0], xs[1], xs[2], mask, false, train)}
+}
+
+func (layer *Attention) Score(q, k, v, mask *tensor.Tensor, isCausal, train bool) *tensor.Tensor {
+	q, k, _ = layer.project(q, k, k, 0)
+	k = layer.repeat(k)
 	if isCausal {
-		mask = buildCausal(q, k, layer.device)
+		mask = addMask(mask, buildCausal(q, k, 0, layer.device))
 	}
+	mask = addMask(mask, buildPositionBias(q, k, layer.alibi, layer.window, 0, layer.device))
 	score := q.MatMul(k.Transpose(-2, -1)).Div(layer.scale) // (batch, heads, seq, dims/heads)
 	if mask != nil {
 		score = score.Add(mask) // (batch, heads, seq, dims/heads)
@@ -110,10 +256,11 @@ func (layer *Attention) Score(q, k, v, mask *tensor.Tensor, isCausal, train bool
 	return score.Softmax(-1) // (batch, heads, seq, dims/heads)
 }
 
-func buildFreqs(device consts.DeviceType, base, dim, seq int64) *tensor.Tensor {
+func buildFreqs(device consts.DeviceType, base, dim, seq int64, scaling ropeScaling) *tensor.Tensor {
+	inv, mscale := scaling.invFreqs(base, dim)
 	data := make([]float32, dim/2)
-	for i := int64(0); i < dim/2; i++ {
-		data[i] = float32(1 / math.Pow(float64(base), float64(2*i)/float64(dim)))
+	for i := range data {
+		data[i] = float32(inv[i])
 	}
 	freqs := tensor.FromFloat32(data,
 		tensor.WithShapes(dim/2),
@@ -128,87 +275,167 @@ func buildFreqs(device consts.DeviceType, base, dim, seq int64) *tensor.Tensor {
 	freqs = tensor.Outer(t, freqs)
 	data = make([]float32, freqs.ElemCount())
 	for i := range data {
-		data[i] = 1
+		data[i] = float32(mscale)
 	}
-	ones := tensor.FromFloat32(data,
+	abs := tensor.FromFloat32(data,
 		tensor.WithShapes(freqs.Shapes()...),
 		tensor.WithDevice(device))
-	return tensor.Polar(ones, freqs).View(1, seq, 1, -1)
+	return tensor.Polar(abs, freqs).View(1, seq, 1, -1)
 }
 
-func (layer *Attention) applyROPE(q, k *tensor.Tensor, seq int64) (*tensor.Tensor, *tensor.Tensor) {
-	qShapes := q.Shapes()
-	kShapes := k.Shapes()
-	xq := q.Reshape(append(qShapes[:len(qShapes)-1], -1, 2)...).
-		ToScalarType(consts.KFloat)
-	xk := k.Reshape(append(kShapes[:len(kShapes)-1], -1, 2)...).
+func (layer *Attention) applyROPE(q, k *tensor.Tensor, offset int64) (*tensor.Tensor, *tensor.Tensor) {
+	return layer.rotate(q, offset), layer.rotate(k, offset)
+}
+
+// rotate applies RoPE to x (batch, seq, heads, dims/heads) at positions begin from offset
+func (layer *Attention) rotate(x *tensor.Tensor, offset int64) *tensor.Tensor {
+	shapes := x.Shapes()
+	seq := shapes[1]
+	dim := shapes[len(shapes)-1]
+	xc := x.Reshape(append(shapes[:len(shapes)-1], -1, 2)...).
 		ToScalarType(consts.KFloat)
 	if layer.device == consts.KMPS {
-		xq = xq.ToDevice(consts.KCPU)
-		xk = xk.ToDevice(consts.KCPU)
+		xc = xc.ToDevice(consts.KCPU)
 	}
-	xq = xq.ViewAsComplex()
-	xk = xk.ViewAsComplex()
-	if layer.freqs == nil || layer.freqs.Shapes()[1] < seq {
-		layer.freqs = buildFreqs(q.DeviceType(), layer.ropeBase, qShapes[len(qShapes)-1], seq)
+	xc = xc.ViewAsComplex()
+	if layer.freqs == nil || layer.freqs.Shapes()[1] < offset+seq {
+		layer.freqs = buildFreqs(x.DeviceType(), layer.ropeBase, dim, offset+seq, layer.ropeScaling)
 	}
-	freqs := layer.freqs.NArrow(1, 0, seq)
+	freqs := layer.freqs.NArrow(1, offset, seq)
 	if layer.device == consts.KMPS {
 		freqs = freqs.ToDevice(consts.KCPU)
 	}
-	xq = xq.Mul(freqs).ViewAsReal().Flatten(3, -1).
-		ToDevice(q.DeviceType()).ToScalarType(q.ScalarType())
-	xk = xk.Mul(freqs).ViewAsReal().Flatten(3, -1).
-		ToDevice(k.DeviceType()).ToScalarType(k.ScalarType())
-	return xq, xk
+	return xc.Mul(freqs).ViewAsReal().Flatten(3, -1).
+		ToDevice(x.DeviceType()).ToScalarType(x.ScalarType())
 }
 
-func (layer *Attention) split(x *tensor.Tensor) *tensor.Tensor {
-	return x.View(-1, x.Shapes()[1], int64(layer.heads), int64(layer.dims/layer.heads))
+func (layer *Attention) split(x *tensor.Tensor, heads int) *tensor.Tensor {
+	return x.View(-1, x.Shapes()[1], int64(heads), int64(layer.dims/layer.heads))
+}
+
+// repeat repeats k or v (batch, kvHeads, seq, dims/heads) to (batch, heads, seq, dims/heads)
+func (layer *Attention) repeat(x *tensor.Tensor) *tensor.Tensor {
+	return repeatKV(x, int64(layer.heads/layer.kvHeads))
 }
 
 func (layer *Attention) Params() []*tensor.Tensor {
-	return []*tensor.Tensor{
-		layer.q,
-		layer.k,
-		layer.v,
+	ret := []*tensor.Tensor{layer.q, layer.k, layer.v}
+	if layer.o != nil {
+		ret = append(ret, layer.o)
+	}
+	if layer.bq != nil {
+		ret = append(ret, layer.bq, layer.bk, layer.bv)
+		if layer.bo != nil {
+			ret = append(ret, layer.bo)
+		}
 	}
+	return ret
+}
+
+func (layer *Attention) SetParams(params []*tensor.Tensor) {
+	dst := []**tensor.Tensor{&layer.q, &layer.k, &layer.v}
+	if layer.o != nil {
+		dst = append(dst, &layer.o)
+	}
+	if layer.bq != nil {
+		dst = append(dst, &layer.bq, &layer.bk, &layer.bv)
+		if layer.bo != nil {
+			dst = append(dst, &layer.bo)
+		}
+	}
+	setParams(params, dst...)
+}
+
+func (layer *Attention) ParamNames() []string {
+	ret := []string{"q", "k", "v"}
+	if layer.o != nil {
+		ret = append(ret, "o")
+	}
+	if layer.bq != nil {
+		ret = append(ret, "bq", "bk", "bv")
+		if layer.bo != nil {
+			ret = append(ret, "bo")
+		}
+	}
+	return ret
 }
 
 func (layer *Attention) Args() map[string]float32 {
-	var rope float32
+	var rope, alibi, outProj, qkvBias float32
 	if layer.rope {
 		rope = 1
 	}
+	if layer.alibi {
+		alibi = 1
+	}
+	if layer.o != nil {
+		outProj = 1
+	}
+	if layer.bq != nil {
+		qkvBias = 1
+	}
 	return map[string]float32{
-		"dims":      float32(layer.dims),
-		"heads":     float32(layer.heads),
-		"dropout":   float32(layer.dropout),
-		"rope":      rope,
-		"rope_base": float32(layer.ropeBase),
+		"dims":                 float32(layer.dims),
+		"heads":                float32(layer.heads),
+		"num_kv_heads":         float32(layer.kvHeads),
+		"dropout":              float32(layer.dropout),
+		"rope":                 rope,
+		"rope_base":            float32(layer.ropeBase),
+		"rope_scaling":         float32(layer.ropeScaling.kind),
+		"rope_factor":          float32(layer.ropeScaling.factor),
+		"rope_original_length": float32(layer.ropeScaling.original),
+		"alibi":                alibi,
+		"window":               float32(layer.window),
+		"out_proj":             outProj,
+		"qkv_bias":             qkvBias,
 	}
 }
 
 func (layer *Attention) Freeze() {
-	layer.q.SetRequiresGrad(false)
-	layer.k.SetRequiresGrad(false)
-	layer.v.SetRequiresGrad(false)
+	for _, p := range layer.Params() {
+		p.SetRequiresGrad(false)
+	}
 }
 
 func (layer *Attention) Unfreeze() {
-	layer.q.SetRequiresGrad(true)
-	layer.k.SetRequiresGrad(true)
-	layer.v.SetRequiresGrad(true)
+	for _, p := range layer.Params() {
+		p.SetRequiresGrad(true)
+	}
 }
 
 func (layer *Attention) ToScalarType(t consts.ScalarType) {
-	layer.q = layer.q.ToScalarType(t)
-	layer.k = layer.k.ToScalarType(t)
-	layer.v = layer.v.ToScalarType(t)
+	for _, p := range []**tensor.Tensor{
+		&layer.q, &layer.k, &layer.v, &layer.o,
+		&layer.bq, &layer.bk, &layer.bv, &layer.bo,
+	} {
+		if *p != nil {
+			*p = (*p).ToScalarType(t)
+		}
+	}
 }
 
 func (layer *Attention) Reset() {
 	layer.q = layer.initW(layer.q.Shapes()...)
 	layer.k = layer.initW(layer.k.Shapes()...)
 	layer.v = layer.initW(layer.v.Shapes()...)
+	if layer.o != nil {
+		layer.o = layer.initW(layer.o.Shapes()...)
+	}
+	for _, p := range []**tensor.Tensor{&layer.bq, &layer.bk, &layer.bv, &layer.bo} {
+		if *p != nil {
+			*p = layer.zeros((*p).Shapes()...)
+		}
+	}
+}
+
+// repeatKV repeats each head of x (batch, kvHeads, seq, dim) groups times
+// to (batch, kvHeads*groups, seq, dim)
+func repeatKV(x *tensor.Tensor, groups int64) *tensor.Tensor {
+	if groups == 1 {
+		return x
+	}
+	shapes := x.Shapes()
+	return x.Unsqueeze(2).
+		Expand(shapes[0], shapes[1], groups, shapes[2], shapes[3]).
+		Reshape(shapes[0], shapes[1]*groups, shapes[2], shapes[3])
 }
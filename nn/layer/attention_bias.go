@@ -0,0 +1,77 @@
+package layer
+
+import (
+	"math"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+// alibiSlopes returns the slope of each head in ALiBi, the geometric sequence
+// begins at 2^(-8/n) for n heads, the heads beyond the closest power of 2 interleave
+// the sequence of 2n heads
+func alibiSlopes(heads int) []float64 {
+	pow2 := func(n int) []float64 {
+		start := math.Pow(2, -8/float64(n))
+		ret := make([]float64, n)
+		for i := range ret {
+			ret[i] = math.Pow(start, float64(i+1))
+		}
+		return ret
+	}
+	n := 1 << int(math.Floor(math.Log2(float64(heads))))
+	ret := pow2(n)
+	if n < heads {
+		extra := pow2(2 * n)
+		for i := 0; i < heads-n; i++ {
+			ret = append(ret, extra[2*i])
+		}
+	}
+	return ret
+}
+
+// buildPositionBias builds the ALiBi bias and the sliding window mask of q (batch, heads, l, dims)
+// attending to k (batch, heads, s, dims), the query i is at position offset+i and the key j is at j.
+// It returns nil when both of them are disabled
+func buildPositionBias(q, k *tensor.Tensor, alibi bool, window int, offset int64, device consts.DeviceType) *tensor.Tensor {
+	if !alibi && window <= 0 {
+		return nil
+	}
+	heads := int64(1)
+	var slopes []float64
+	if alibi {
+		heads = q.Shapes()[1]
+		slopes = alibiSlopes(int(heads))
+	}
+	l := q.Shapes()[q.Dims()-2]
+	s := k.Shapes()[k.Dims()-2]
+	bias := make([]float32, heads*l*s)
+	for h := int64(0); h < heads; h++ {
+		for i := int64(0); i < l; i++ {
+			for j := int64(0); j < s; j++ {
+				distance := math.Abs(float64(offset + i - j))
+				idx := (h*l+i)*s + j
+				switch {
+				case window > 0 && distance >= float64(window):
+					bias[idx] = float32(math.Inf(-1))
+				case alibi:
+					bias[idx] = float32(-slopes[h] * distance)
+				}
+			}
+		}
+	}
+	return tensor.FromFloat32(bias,
+		tensor.WithShapes(1, heads, l, s),
+		tensor.WithDevice(device)).ToScalarType(q.ScalarType())
+}
+
+// addMask returns the sum of the additive masks a and b, any of them can be nil
+func addMask(a, b *tensor.Tensor) *tensor.Tensor {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	return a.Add(b)
+}
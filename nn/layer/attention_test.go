@@ -2,6 +2,7 @@ package layer
 
 import (
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/lwch/gotorch/consts"
@@ -32,7 +33,7 @@ func TestAttentionScore(t *testing.T) {
 func TestXxx(*testing.T) {
 	const seq = 16
 	const dim = 1024
-	freq := buildFreqs(consts.KCPU, 4096, dim, seq)
+	freq := buildFreqs(consts.KCPU, 4096, dim, seq, ropeScaling{})
 	data := make([]float32, seq*dim)
 	for i := int64(0); i < seq*dim; i++ {
 		data[i] = 1
@@ -46,3 +47,129 @@ func TestXxx(*testing.T) {
 		fmt.Println(data[i*dim : (i+1)*dim])
 	}
 }
+
+// cacheTol is the tolerance of the outputs computed with KVCache against the full forward,
+// the cached steps multiply shorter matrices, so the summation order differs
+const cacheTol = 1e-5
+
+func TestAttentionCache(t *testing.T) {
+	x := tensor.ARange(1*5*8, consts.KFloat).Reshape(1, 5, 8).Div(
+		tensor.FromFloat32([]float32{40}, tensor.WithShapes(1)))
+	check := func(name string, full func() *tensor.Tensor, step func(x *tensor.Tensor, cache *KVCache) *tensor.Tensor) {
+		expect := full().Float32Value()
+		cache := NewKVCache()
+		var list []*tensor.Tensor
+		for _, n := range [][2]int64{{0, 2}, {2, 1}, {3, 2}} {
+			list = append(list, step(x.NArrow(1, n[0], n[1]), cache))
+		}
+		if cache.Len() != 5 {
+			t.Fatalf("%s: unexpected cache size %d", name, cache.Len())
+		}
+		assertCloseTol(t, name, tensor.Cat(list, 1).Float32Value(), expect, cacheTol)
+	}
+	attn := NewAttention("attn", 8, 2, 0, true)
+	check("attention", func() *tensor.Tensor {
+		return attn.Forward(x, x, x, nil, true, false)
+	}, func(x *tensor.Tensor, cache *KVCache) *tensor.Tensor {
+		return attn.ForwardCache(x, nil, cache, false)
+	})
+	attn1 := NewAttention1("attn1", 8, 2, 0, true)
+	check("attention1", func() *tensor.Tensor {
+		return attn1.Forward(x, x, x, nil, true, false)
+	}, func(x *tensor.Tensor, cache *KVCache) *tensor.Tensor {
+		return attn1.ForwardCache(x, nil, cache, false)
+	})
+}
+
+func TestAttentionKVHeads(t *testing.T) {
+	x := tensor.ARange(2*3*8, consts.KFloat).Reshape(2, 3, 8)
+	for _, l := range []interface {
+		Layer
+		SetKVHeads(int)
+		Forward(q, k, v, mask *tensor.Tensor, isCausal, train bool) *tensor.Tensor
+		ForwardCache(x, mask *tensor.Tensor, cache *KVCache, train bool) *tensor.Tensor
+	}{
+		NewAttention("attn", 8, 4, 0, true),
+		NewAttention1("attn1", 8, 4, 0, true),
+	} {
+		l.SetKVHeads(2)
+		if shapes := l.Params()[1].Shapes(); shapes[0] != 4 || shapes[1] != 8 {
+			t.Fatalf("%s: unexpected k shapes %v", l.Class(), shapes)
+		}
+		if l.Args()["num_kv_heads"] != 2 {
+			t.Fatalf("%s: num_kv_heads not saved", l.Class())
+		}
+		y := l.Forward(x, x, x, nil, true, false)
+		if shapes := y.Shapes(); shapes[0] != 2 || shapes[1] != 3 || shapes[2] != 8 {
+			t.Fatalf("%s: unexpected output shapes %v", l.Class(), shapes)
+		}
+		cache := NewKVCache()
+		l.ForwardCache(x, nil, cache, false)
+		if heads := cache.k.Shapes()[1]; heads != 2 {
+			t.Fatalf("%s: cache should keep the kv heads only, got %d", l.Class(), heads)
+		}
+	}
+}
+
+func TestAttentionOutputBias(t *testing.T) {
+	x := tensor.ARange(1*3*4, consts.KFloat).Reshape(1, 3, 4)
+	l := NewAttention("attn", 4, 2, 0, false)
+	l.SetOutputProjection(true)
+	l.SetQKVBias(true)
+	names := l.ParamNames()
+	if len(names) != 8 || names[3] != "o" || names[7] != "bo" {
+		t.Fatalf("unexpected param names: %v", names)
+	}
+	loaded := LoadAttention("attn", l.Params(), l.Args()).(*Attention)
+	assertClose(t, "attention", loaded.Forward(x, x, x, nil, true, false).Float32Value(),
+		l.Forward(x, x, x, nil, true, false).Float32Value())
+
+	l.SetQKVBias(false)
+	if len(l.Params()) != 4 {
+		t.Fatalf("unexpected params count: %d", len(l.Params()))
+	}
+}
+
+func TestALiBiSlopes(t *testing.T) {
+	slopes := alibiSlopes(8)
+	for i, s := range slopes {
+		if want := math.Pow(2, -float64(i+1)); math.Abs(s-want) > 1e-9 {
+			t.Fatalf("unexpected slope %d: %f != %f", i, s, want)
+		}
+	}
+	if len(alibiSlopes(6)) != 6 {
+		t.Fatal("unexpected slopes count")
+	}
+}
+
+func TestAttentionPositionBias(t *testing.T) {
+	x := tensor.ARange(1*5*8, consts.KFloat).Reshape(1, 5, 8).Div(
+		tensor.FromFloat32([]float32{40}, tensor.WithShapes(1)))
+	attn := NewAttention("attn", 8, 2, 0, false)
+	attn1 := NewAttention1("attn1", 8, 2, 0, false)
+	for _, l := range []interface {
+		SetALiBi(bool)
+		SetWindow(int)
+		Forward(q, k, v, mask *tensor.Tensor, isCausal, train bool) *tensor.Tensor
+		ForwardCache(x, mask *tensor.Tensor, cache *KVCache, train bool) *tensor.Tensor
+	}{attn, attn1} {
+		l.SetALiBi(true)
+		l.SetWindow(2)
+		expect := l.Forward(x, x, x, nil, true, false).Float32Value()
+		cache := NewKVCache()
+		var list []*tensor.Tensor
+		for i := int64(0); i < 5; i++ {
+			list = append(list, l.ForwardCache(x.NArrow(1, i, 1), nil, cache, false))
+		}
+		assertCloseTol(t, "position bias", tensor.Cat(list, 1).Float32Value(), expect, cacheTol)
+	}
+	// the window covering the whole sequence changes nothing
+	attn.SetALiBi(false)
+	attn.SetWindow(0)
+	expect := attn.Forward(x, x, x, nil, true, false).Float32Value()
+	attn.SetWindow(5)
+	assertClose(t, "window", attn.Forward(x, x, x, nil, true, false).Float32Value(), expect)
+	if args := LoadAttention("attn", attn.Params(), attn.Args()).Args(); args["window"] != 5 {
+		t.Fatalf("window not loaded: %v", args)
+	}
+}
@@ -0,0 +1,30 @@
+package layer
+
+import (
+	"github.com/lwch/gotorch/tensor"
+)
+
+type AvgPool1D struct {
+	avgPooling
+}
+
+func NewAvgPool1D(name string, kernel int, opts ...LayerCreateOption) *AvgPool1D {
+	var layer AvgPool1D
+	layer.init("avgpool1d", name, kernel, opts...)
+	return &layer
+}
+
+func LoadAvgPool1D(name string, _ []*tensor.Tensor, args map[string]float32) Layer {
+	var layer AvgPool1D
+	layer.load("avgpool1d", name, args)
+	return &layer
+}
+
+// Forward pools x (batch, channels, length) or (channels, length)
+func (layer *AvgPool1D) Forward(x *tensor.Tensor) *tensor.Tensor {
+	return x.AvgPool1D(layer.kernel, layer.opts()...)
+}
+
+func (layer *AvgPool1D) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
@@ -0,0 +1,30 @@
+package layer
+
+import (
+	"github.com/lwch/gotorch/tensor"
+)
+
+type AvgPool2D struct {
+	avgPooling
+}
+
+func NewAvgPool2D(name string, kernel int, opts ...LayerCreateOption) *AvgPool2D {
+	var layer AvgPool2D
+	layer.init("avgpool2d", name, kernel, opts...)
+	return &layer
+}
+
+func LoadAvgPool2D(name string, _ []*tensor.Tensor, args map[string]float32) Layer {
+	var layer AvgPool2D
+	layer.load("avgpool2d", name, args)
+	return &layer
+}
+
+// Forward pools x (batch, channels, height, width) or (channels, height, width)
+func (layer *AvgPool2D) Forward(x *tensor.Tensor) *tensor.Tensor {
+	return x.AvgPool2D(layer.kernel, layer.opts()...)
+}
+
+func (layer *AvgPool2D) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
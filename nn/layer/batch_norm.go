@@ -0,0 +1,182 @@
+package layer
+
+import (
+	"fmt"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+// batchNorm normalizes each channel by the statistics of the batch in train mode and the running
+// statistics in eval mode, the running statistics are updated in train mode by
+// running = (1-momentum)*running + momentum*batch
+type batchNorm struct {
+	channelNorm
+	dims     []int64 // supported input dims
+	momentum float64
+	// buffers
+	runningMean *tensor.Tensor
+	runningVar  *tensor.Tensor
+	// momentum and 1-momentum cached by scalars
+	m, keep *tensor.Tensor
+}
+
+func (l *batchNorm) init(class, name string, channels int64, dims []int64, opts ...LayerCreateOption) {
+	l.channelNorm.init(class, name, channels, true, opts...)
+	l.dims = dims
+	l.momentum = 0.1
+	l.resetBuffers()
+}
+
+func (l *batchNorm) load(class, name string, dims []int64, params []*tensor.Tensor, args map[string]float32) {
+	l.channelNorm.load(class, name, params, args)
+	l.dims = dims
+	l.momentum = float64(args["momentum"])
+	l.resetBuffers()
+}
+
+// SetMomentum sets the weight of the batch statistics in the running statistics, default is 0.1
+func (l *batchNorm) SetMomentum(momentum float64) {
+	l.momentum = momentum
+	l.m, l.keep = nil, nil
+}
+
+func (l *batchNorm) resetBuffers() {
+	l.runningMean = tensor.Zeros(l.paramType,
+		tensor.WithDevice(l.device),
+		tensor.WithShapes(l.channels))
+	l.runningVar = l.ones(l.channels)
+}
+
+func (l *batchNorm) Forward(x *tensor.Tensor, train bool) *tensor.Tensor {
+	supported := false
+	for _, dims := range l.dims {
+		supported = supported || x.Dims() == dims
+	}
+	if !supported {
+		panic(fmt.Errorf("%s: unexpected input dims %d", l.class, x.Dims()))
+	}
+	shapes := l.statShapes(x)
+	if !train {
+		return l.normalize(x,
+			l.runningMean.Reshape(shapes...),
+			l.runningVar.Reshape(shapes...))
+	}
+	y := x.Transpose(0, 1).Reshape(l.channels, -1) // (channels, batch*...)
+	mean := y.Mean(1, false)
+	l.update(mean, y.Var(1, true, false))
+	return l.normalize(x,
+		mean.Reshape(shapes...),
+		y.Var(1, false, false).Reshape(shapes...))
+}
+
+// scalars returns momentum and 1-momentum, they are rebuilt only when the momentum,
+// the scalar type of the running statistics or the device is changed
+func (l *batchNorm) scalars(device consts.DeviceType) (*tensor.Tensor, *tensor.Tensor) {
+	t := l.runningMean.ScalarType()
+	if l.m == nil || l.m.ScalarType() != t || l.m.DeviceType() != device {
+		opts := []tensor.Option{tensor.WithShapes(1), tensor.WithDevice(device)}
+		l.m = tensor.FromFloat64([]float64{l.momentum}, opts...).ToScalarType(t)
+		l.keep = tensor.FromFloat64([]float64{1 - l.momentum}, opts...).ToScalarType(t)
+	}
+	return l.m, l.keep
+}
+
+// update updates the running statistics by the batch mean and unbiased variance,
+// they are detached from the graph together so the data goes through host memory once,
+// see detach
+func (l *batchNorm) update(mean, v *tensor.Tensor) {
+	m, keep := l.scalars(mean.DeviceType())
+	mean = mean.ToScalarType(l.runningMean.ScalarType())
+	v = v.ToScalarType(l.runningVar.ScalarType())
+	stats := detach(tensor.Cat([]*tensor.Tensor{
+		l.runningMean.Mul(keep).Add(mean.Mul(m)).Unsqueeze(0),
+		l.runningVar.Mul(keep).Add(v.Mul(m)).Unsqueeze(0),
+	}, 0)) // (2, channels)
+	l.runningMean = stats.NArrow(0, 0, 1).Squeeze(0)
+	l.runningVar = stats.NArrow(0, 1, 1).Squeeze(0)
+}
+
+func (l *batchNorm) Call(x *tensor.Tensor, train bool) *tensor.Tensor {
+	return l.Forward(x, train)
+}
+
+func (l *batchNorm) Buffers() []*tensor.Tensor {
+	return []*tensor.Tensor{
+		l.runningMean,
+		l.runningVar,
+	}
+}
+
+func (l *batchNorm) BufferNames() []string {
+	return []string{
+		"running_mean",
+		"running_var",
+	}
+}
+
+// SetBuffers sets running_mean and running_var, it panics when the shape of any of them is not (channels)
+func (l *batchNorm) SetBuffers(buffers []*tensor.Tensor) {
+	if len(buffers) != 2 {
+		panic(fmt.Errorf("expected 2 buffers, got %d", len(buffers)))
+	}
+	for i, b := range buffers {
+		if shapes := b.Shapes(); len(shapes) != 1 || shapes[0] != l.channels {
+			panic(fmt.Errorf("%s: unexpected shape %v of %s, expected [%d]",
+				l.class, shapes, l.BufferNames()[i], l.channels))
+		}
+	}
+	l.runningMean = buffers[0]
+	l.runningVar = buffers[1]
+}
+
+func (l *batchNorm) Args() map[string]float32 {
+	ret := l.args()
+	ret["momentum"] = float32(l.momentum)
+	return ret
+}
+
+func (l *batchNorm) ToScalarType(t consts.ScalarType) {
+	l.channelNorm.ToScalarType(t)
+	l.runningMean = l.runningMean.ToScalarType(t)
+	l.runningVar = l.runningVar.ToScalarType(t)
+}
+
+func (l *batchNorm) Reset() {
+	l.channelNorm.Reset()
+	l.resetBuffers()
+}
+
+// BatchNorm1D normalizes x (batch, channels) or (batch, channels, length)
+type BatchNorm1D struct {
+	batchNorm
+}
+
+func NewBatchNorm1D(name string, channels int64, opts ...LayerCreateOption) *BatchNorm1D {
+	var layer BatchNorm1D
+	layer.init("batch_norm1d", name, channels, []int64{2, 3}, opts...)
+	return &layer
+}
+
+func LoadBatchNorm1D(name string, params []*tensor.Tensor, args map[string]float32) Layer {
+	var layer BatchNorm1D
+	layer.load("batch_norm1d", name, []int64{2, 3}, params, args)
+	return &layer
+}
+
+// BatchNorm2D normalizes x (batch, channels, height, width)
+type BatchNorm2D struct {
+	batchNorm
+}
+
+func NewBatchNorm2D(name string, channels int64, opts ...LayerCreateOption) *BatchNorm2D {
+	var layer BatchNorm2D
+	layer.init("batch_norm2d", name, channels, []int64{4}, opts...)
+	return &layer
+}
+
+func LoadBatchNorm2D(name string, params []*tensor.Tensor, args map[string]float32) Layer {
+	var layer BatchNorm2D
+	layer.load("batch_norm2d", name, []int64{4}, params, args)
+	return &layer
+}
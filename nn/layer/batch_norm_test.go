@@ -0,0 +1,91 @@
+package layer
+
+import (
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+func TestBatchNorm(t *testing.T) {
+	// channel 0 is 0, 2, 4, 6 and channel 1 is 1, 3, 5, 7
+	x := tensor.ARange(4*2, consts.KFloat).Reshape(4, 2)
+	bn := NewBatchNorm1D("bn", 2)
+	bn.SetMomentum(1)
+	y := bn.Forward(x, true).Float32Value()
+	if y[0] >= 0 || y[6] <= 0 || y[0] != y[1] {
+		t.Fatalf("unexpected output: %v", y)
+	}
+	// the unbiased variance of 0, 2, 4, 6 is 20/3
+	assertClose(t, "running mean", bn.runningMean.Float32Value(), []float32{3, 4})
+	assertClose(t, "running var", bn.runningVar.Float32Value(), []float32{20.0 / 3, 20.0 / 3})
+	if len(bn.Params()) != 2 || len(bn.Buffers()) != 2 {
+		t.Fatalf("unexpected params %d and buffers %d", len(bn.Params()), len(bn.Buffers()))
+	}
+
+	bn2d := NewBatchNorm2D("bn", 3)
+	img := tensor.ARange(2*3*4*4, consts.KFloat).Reshape(2, 3, 4, 4)
+	assertShapes(t, "batch norm 2d", bn2d.Forward(img, false), 2, 3, 4, 4)
+}
+
+func TestGroupNorm(t *testing.T) {
+	x := tensor.ARange(2*4*3, consts.KFloat).Reshape(2, 4, 3)
+	gn := NewGroupNorm("gn", 2, 4)
+	y := gn.Forward(x)
+	// each group of each sample has zero mean
+	means := y.Reshape(2, 2, -1).Mean(-1, false).Float32Value()
+	assertClose(t, "group mean", means, []float32{0, 0, 0, 0})
+	loaded := LoadGroupNorm("gn", gn.Params(), gn.Args()).(*GroupNorm)
+	assertClose(t, "group norm", loaded.Forward(x).Float32Value(), y.Float32Value())
+}
+
+func TestInstanceNorm(t *testing.T) {
+	x := tensor.ARange(2*3*4*4, consts.KFloat).Reshape(2, 3, 4, 4)
+	in := NewInstanceNorm("in", 3)
+	if len(in.Params()) != 0 {
+		t.Fatal("instance norm should not be affine by default")
+	}
+	means := in.Forward(x).Reshape(2, 3, -1).Mean(-1, false).Float32Value()
+	assertClose(t, "instance mean", means, []float32{0, 0, 0, 0, 0, 0})
+}
+
+func TestBatchNormScalars(t *testing.T) {
+	bn := NewBatchNorm1D("bn", 2)
+	m, keep := bn.scalars(consts.KCPU)
+	if m2, keep2 := bn.scalars(consts.KCPU); m2 != m || keep2 != keep {
+		t.Fatal("momentum scalars are not cached")
+	}
+	bn.SetMomentum(0.5)
+	m, keep = bn.scalars(consts.KCPU)
+	assertClose(t, "momentum", m.Float32Value(), []float32{0.5})
+	assertClose(t, "keep", keep.Float32Value(), []float32{0.5})
+	bn.ToScalarType(consts.KDouble)
+	if m, _ = bn.scalars(consts.KCPU); m.ScalarType() != consts.KDouble {
+		t.Fatalf("unexpected scalar type %v", m.ScalarType())
+	}
+}
+
+func TestBatchNormSetBuffers(t *testing.T) {
+	bn := NewBatchNorm1D("bn", 2)
+	set := func(buffers ...*tensor.Tensor) (ok bool) {
+		defer func() {
+			ok = recover() == nil
+		}()
+		bn.SetBuffers(buffers)
+		return
+	}
+	mean := tensor.FromFloat32([]float32{1, 2}, tensor.WithShapes(2))
+	if !set(mean, tensor.FromFloat32([]float32{3, 4}, tensor.WithShapes(2))) {
+		t.Fatal("buffers with shape (channels) should be accepted")
+	}
+	assertClose(t, "running var", bn.runningVar.Float32Value(), []float32{3, 4})
+	if set(mean, tensor.FromFloat32([]float32{3, 4, 5}, tensor.WithShapes(3))) {
+		t.Fatal("running var with 3 channels should be rejected")
+	}
+	if set(tensor.FromFloat32([]float32{1, 2}, tensor.WithShapes(1, 2)), mean) {
+		t.Fatal("running mean with shape (1, 2) should be rejected")
+	}
+	if set(mean) {
+		t.Fatal("missing running var should be rejected")
+	}
+}
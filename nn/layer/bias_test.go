@@ -0,0 +1,66 @@
+package layer
+
+import (
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+func TestBias(t *testing.T) {
+	type biasLayer interface {
+		Layer
+		Forward(x *tensor.Tensor) *tensor.Tensor
+	}
+	seq := tensor.ARange(1*2*5, consts.KFloat).Reshape(1, 2, 5)
+	img := tensor.ARange(1*2*5*5, consts.KFloat).Reshape(1, 2, 5, 5)
+	for _, c := range []struct {
+		new  func(opts ...LayerCreateOption) biasLayer
+		load func(name string, params []*tensor.Tensor, args map[string]float32) Layer
+		x    *tensor.Tensor
+	}{
+		{func(opts ...LayerCreateOption) biasLayer {
+			return NewLinear("linear", 5, 3, opts...)
+		}, LoadLinear, seq},
+		{func(opts ...LayerCreateOption) biasLayer {
+			return NewConv1D("conv1d", 2, 3, 2, opts...)
+		}, LoadConv1D, seq},
+		{func(opts ...LayerCreateOption) biasLayer {
+			return NewConv2D("conv2d", 2, 3, 2, 2, opts...)
+		}, LoadConv2D, img},
+		{func(opts ...LayerCreateOption) biasLayer {
+			return NewConvTranspose1D("convtranspose1d", 2, 3, 2, opts...)
+		}, LoadConvTranspose1D, seq},
+		{func(opts ...LayerCreateOption) biasLayer {
+			return NewConvTranspose2D("convtranspose2d", 2, 3, 2, 2, opts...)
+		}, LoadConvTranspose2D, img},
+	} {
+		for _, bias := range []bool{true, false} {
+			l := c.new(WithBias(bias))
+			count := 1
+			if bias {
+				count = 2
+			}
+			if len(l.Params()) != count || len(l.ParamNames()) != count {
+				t.Fatalf("%s(bias=%v): unexpected params count %d", l.Class(), bias, len(l.Params()))
+			}
+			if (l.Args()["bias"] != 0) != bias {
+				t.Fatalf("%s(bias=%v): unexpected bias arg %f", l.Class(), bias, l.Args()["bias"])
+			}
+			loaded := c.load(l.Name(), l.Params(), l.Args()).(biasLayer)
+			if len(loaded.Params()) != count {
+				t.Fatalf("%s(bias=%v): unexpected loaded params count %d", l.Class(), bias, len(loaded.Params()))
+			}
+			assertClose(t, l.Class(), loaded.Forward(c.x).Float32Value(), l.Forward(c.x).Float32Value())
+		}
+
+		// checkpoints saved before the bias was added have no bias arg and only the weight
+		l := c.new(WithBias(false))
+		args := l.Args()
+		delete(args, "bias")
+		loaded := c.load(l.Name(), l.Params(), args).(biasLayer)
+		if len(loaded.Params()) != 1 || loaded.Args()["bias"] != 0 {
+			t.Fatalf("%s: old checkpoint should load without bias", l.Class())
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package layer
+
+import (
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+// channelNorm is the common part of the norm layers on x (batch, channels, ...),
+// the learnable scale a and bias b are per channel
+type channelNorm struct {
+	base
+	channels int64
+	eps      float64
+	// runtime
+	epsT *tensor.Tensor
+	// params
+	a *tensor.Tensor
+	b *tensor.Tensor
+}
+
+func (l *channelNorm) init(class, name string, channels int64, affine bool, opts ...LayerCreateOption) {
+	l.new(class, name, opts...)
+	l.channels = channels
+	l.eps = 1e-5
+	l.epsT = l.initN(l.eps)
+	l.SetAffine(affine)
+}
+
+func (l *channelNorm) load(class, name string, params []*tensor.Tensor, args map[string]float32) {
+	l.new(class, name)
+	l.channels = int64(args["channels"])
+	l.eps = float64(args["eps"])
+	if args["affine"] != 0 {
+		l.paramType = params[0].ScalarType()
+		l.a = params[0]
+		if args["bias"] != 0 {
+			l.b = params[1]
+		}
+	}
+	l.epsT = l.initN(l.eps)
+}
+
+func (l *channelNorm) SetEps(eps float64) {
+	l.eps = eps
+	l.epsT = l.initN(eps)
+}
+
+// SetAffine enable or disable the learnable scale and bias
+func (l *channelNorm) SetAffine(affine bool) {
+	if !affine {
+		l.a = nil
+		l.b = nil
+		return
+	}
+	if l.a == nil {
+		l.a = l.ones(l.channels)
+		l.a.SetRequiresGrad(true)
+	}
+	if l.b == nil && l.bias {
+		l.b = l.zeros(l.channels)
+	}
+}
+
+// statShapes returns (1, channels, 1, ...) which broadcasts to x
+func (l *channelNorm) statShapes(x *tensor.Tensor) []int64 {
+	ret := make([]int64, x.Dims())
+	for i := range ret {
+		ret[i] = 1
+	}
+	ret[1] = l.channels
+	return ret
+}
+
+// normalize computes (x-mean)/sqrt(v+eps)*a+b, mean and v broadcast to x
+func (l *channelNorm) normalize(x, mean, v *tensor.Tensor) *tensor.Tensor {
+	y := x.Sub(mean).Div(v.Add(l.epsT.ToDevice(x.DeviceType())).Sqrt())
+	return l.affine(y)
+}
+
+func (l *channelNorm) affine(y *tensor.Tensor) *tensor.Tensor {
+	shapes := l.statShapes(y)
+	if l.a != nil {
+		y = y.Mul(l.a.Reshape(shapes...))
+	}
+	if l.b != nil {
+		y = y.Add(l.b.Reshape(shapes...))
+	}
+	return y
+}
+
+func (l *channelNorm) Params() []*tensor.Tensor {
+	var ret []*tensor.Tensor
+	if l.a != nil {
+		ret = append(ret, l.a)
+	}
+	if l.b != nil {
+		ret = append(ret, l.b)
+	}
+	return ret
+}
+
+func (l *channelNorm) SetParams(params []*tensor.Tensor) {
+	var dst []**tensor.Tensor
+	if l.a != nil {
+		dst = append(dst, &l.a)
+	}
+	if l.b != nil {
+		dst = append(dst, &l.b)
+	}
+	setParams(params, dst...)
+}
+
+func (l *channelNorm) ParamNames() []string {
+	var ret []string
+	if l.a != nil {
+		ret = append(ret, "a")
+	}
+	if l.b != nil {
+		ret = append(ret, "b")
+	}
+	return ret
+}
+
+func (l *channelNorm) args() map[string]float32 {
+	var affine, bias float32
+	if l.a != nil {
+		affine = 1
+	}
+	if l.b != nil {
+		bias = 1
+	}
+	return map[string]float32{
+		"channels": float32(l.channels),
+		"eps":      float32(l.eps),
+		"affine":   affine,
+		"bias":     bias,
+	}
+}
+
+func (l *channelNorm) Freeze() {
+	for _, p := range l.Params() {
+		p.SetRequiresGrad(false)
+	}
+}
+
+func (l *channelNorm) Unfreeze() {
+	for _, p := range l.Params() {
+		p.SetRequiresGrad(true)
+	}
+}
+
+func (l *channelNorm) ToScalarType(t consts.ScalarType) {
+	if l.a != nil {
+		l.a = l.a.ToScalarType(t)
+	}
+	if l.b != nil {
+		l.b = l.b.ToScalarType(t)
+	}
+}
+
+func (l *channelNorm) Reset() {
+	if l.a != nil {
+		l.a = l.ones(l.channels)
+		l.a.SetRequiresGrad(true)
+	}
+	if l.b != nil {
+		l.b = l.zeros(l.channels)
+	}
+}
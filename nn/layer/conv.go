@@ -0,0 +1,59 @@
+package layer
+
+import (
+	"fmt"
+
+	"github.com/lwch/gotorch/tensor"
+)
+
+// samePadding pads x on the spatial dims so that the output size equals to the input size,
+// it returns the padded x and the padding for each side given to the convolution,
+// when the total padding is odd the extra one is padded to the end like pytorch
+func samePadding(x *tensor.Tensor, kernel, stride, dilation []int) (*tensor.Tensor, []int) {
+	padding := make([]int, len(kernel))
+	offset := int(x.Dims()) - len(kernel)
+	for i := range kernel {
+		if stride[i] != 1 {
+			panic(fmt.Errorf("same padding only supports stride 1, got %v", stride))
+		}
+		total := dilation[i] * (kernel[i] - 1)
+		padding[i] = total / 2
+		x = padEnd(x, offset+i, total%2)
+	}
+	return x, padding
+}
+
+// padEnd pads n zeros to the end of dim
+func padEnd(x *tensor.Tensor, dim, n int) *tensor.Tensor {
+	if n == 0 {
+		return x
+	}
+	shapes := x.Shapes()
+	shapes[dim] = int64(n)
+	zeros := tensor.Zeros(x.ScalarType(),
+		tensor.WithDevice(x.DeviceType()),
+		tensor.WithShapes(shapes...))
+	return tensor.Cat([]*tensor.Tensor{x, zeros}, dim)
+}
+
+// dilateKernel inserts dilation-1 zeros between the elements of w on dim,
+// the convolution by the dilated kernel equals to the convolution with dilation on dim
+func dilateKernel(w *tensor.Tensor, dim, dilation int) *tensor.Tensor {
+	if dilation <= 1 {
+		return w
+	}
+	shapes := w.Shapes()
+	size := shapes[dim]
+	// (..., k, ...) => (..., k, d, ...) => (..., k*d, ...)
+	zeroShapes := make([]int64, 0, len(shapes)+1)
+	zeroShapes = append(zeroShapes, shapes[:dim+1]...)
+	zeroShapes = append(zeroShapes, int64(dilation-1))
+	zeroShapes = append(zeroShapes, shapes[dim+1:]...)
+	zeros := tensor.Zeros(w.ScalarType(),
+		tensor.WithDevice(w.DeviceType()),
+		tensor.WithShapes(zeroShapes...))
+	w = tensor.Cat([]*tensor.Tensor{w.Unsqueeze(int64(dim + 1)), zeros}, dim+1)
+	shapes[dim] = size * int64(dilation)
+	w = w.Reshape(shapes...)
+	return w.NArrow(int64(dim), 0, (size-1)*int64(dilation)+1)
+}
@@ -11,10 +11,12 @@ type Conv1D struct {
 	kernel    int
 	stride    int
 	padding   int
+	same      bool
 	dilation  int
 	groups    int
 	// params
 	w *tensor.Tensor
+	b *tensor.Tensor
 }
 
 func NewConv1D(name string, inC, outC, kernel int, opts ...LayerCreateOption) *Conv1D {
@@ -28,6 +30,9 @@ func NewConv1D(name string, inC, outC, kernel int, opts ...LayerCreateOption) *C
 	layer.dilation = 1
 	layer.groups = 1
 	layer.w = layer.initW(int64(outC), int64(inC), int64(kernel))
+	if layer.bias {
+		layer.b = layer.zeros(int64(outC))
+	}
 	return &layer
 }
 
@@ -39,12 +44,22 @@ func (layer *Conv1D) SetPadding(padding int) {
 	layer.padding = padding
 }
 
+// SetSamePadding pads the input to keep the output length, only supports stride 1
+func (layer *Conv1D) SetSamePadding(same bool) {
+	layer.same = same
+}
+
 func (layer *Conv1D) SetDilation(dilation int) {
 	layer.dilation = dilation
 }
 
+// SetGroups reallocates w to (outC, inC/groups, kernel...)
 func (layer *Conv1D) SetGroups(groups int) {
+	if groups <= 0 || layer.inC%groups != 0 || layer.outC%groups != 0 {
+		panic("inC and outC must be divisible by groups")
+	}
 	layer.groups = groups
+	layer.w = layer.initW(int64(layer.outC), int64(layer.inC/groups), int64(layer.kernel))
 }
 
 func LoadConv1D(name string, params []*tensor.Tensor, args map[string]float32) Layer {
@@ -55,50 +70,112 @@ func LoadConv1D(name string, params []*tensor.Tensor, args map[string]float32) L
 	layer.kernel = int(args["kernel"])
 	layer.stride = int(args["stride"])
 	layer.padding = int(args["padding"])
+	layer.same = args["same_padding"] != 0
 	layer.dilation = int(args["dilation"])
 	layer.groups = int(args["groups"])
 	layer.w = params[0]
+	if args["bias"] != 0 {
+		layer.b = params[1]
+	}
 	return &layer
 }
 
 func (layer *Conv1D) Forward(x *tensor.Tensor) *tensor.Tensor {
-	return x.Conv1D(layer.w, nil,
+	padding := layer.padding
+	if layer.same {
+		var paddings []int
+		x, paddings = samePadding(x, []int{layer.kernel},
+			[]int{layer.stride}, []int{layer.dilation})
+		padding = paddings[0]
+	}
+	return x.Conv1D(layer.w, layer.b,
 		tensor.Conv1DStride(layer.stride),
-		tensor.Conv1DPadding(layer.padding),
+		tensor.Conv1DPadding(padding),
 		tensor.Conv1DDilation(layer.dilation),
 		tensor.Conv1DGroups(layer.groups))
 }
 
+func (layer *Conv1D) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
 func (layer *Conv1D) Params() []*tensor.Tensor {
+	if layer.b != nil {
+		return []*tensor.Tensor{
+			layer.w,
+			layer.b,
+		}
+	}
 	return []*tensor.Tensor{
 		layer.w,
 	}
 }
 
+func (layer *Conv1D) SetParams(params []*tensor.Tensor) {
+	dst := []**tensor.Tensor{&layer.w}
+	if layer.b != nil {
+		dst = append(dst, &layer.b)
+	}
+	setParams(params, dst...)
+}
+
+func (layer *Conv1D) ParamNames() []string {
+	if layer.b != nil {
+		return []string{
+			"w",
+			"b",
+		}
+	}
+	return []string{
+		"w",
+	}
+}
+
 func (layer *Conv1D) Args() map[string]float32 {
+	var bias, same float32
+	if layer.b != nil {
+		bias = 1
+	}
+	if layer.same {
+		same = 1
+	}
 	return map[string]float32{
-		"inC":      float32(layer.inC),
-		"outC":     float32(layer.outC),
-		"kernel":   float32(layer.kernel),
-		"stride":   float32(layer.stride),
-		"padding":  float32(layer.padding),
-		"dilation": float32(layer.dilation),
-		"groups":   float32(layer.groups),
+		"inC":          float32(layer.inC),
+		"outC":         float32(layer.outC),
+		"kernel":       float32(layer.kernel),
+		"stride":       float32(layer.stride),
+		"padding":      float32(layer.padding),
+		"same_padding": same,
+		"dilation":     float32(layer.dilation),
+		"groups":       float32(layer.groups),
+		"bias":         bias,
 	}
 }
 
 func (layer *Conv1D) Freeze() {
 	layer.w.SetRequiresGrad(false)
+	if layer.b != nil {
+		layer.b.SetRequiresGrad(false)
+	}
 }
 
 func (layer *Conv1D) Unfreeze() {
 	layer.w.SetRequiresGrad(true)
+	if layer.b != nil {
+		layer.b.SetRequiresGrad(true)
+	}
 }
 
 func (layer *Conv1D) ToScalarType(t consts.ScalarType) {
 	layer.w = layer.w.ToScalarType(t)
+	if layer.b != nil {
+		layer.b = layer.b.ToScalarType(t)
+	}
 }
 
 func (layer *Conv1D) Reset() {
 	layer.w = layer.initW(layer.w.Shapes()...)
+	if layer.b != nil {
+		layer.b = layer.zeros(layer.b.Shapes()...)
+	}
 }
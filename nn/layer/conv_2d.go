@@ -11,10 +11,12 @@ type Conv2D struct {
 	kernel    [2]int
 	stride    [2]int
 	padding   [2]int
-	dilation  int
+	same      bool
+	dilation  [2]int
 	groups    int
 	// params
 	w *tensor.Tensor
+	b *tensor.Tensor
 }
 
 func NewConv2D(name string, inC, outC int, kernel1, kernel2 int, opts ...LayerCreateOption) *Conv2D {
@@ -25,9 +27,12 @@ func NewConv2D(name string, inC, outC int, kernel1, kernel2 int, opts ...LayerCr
 	layer.kernel = [2]int{kernel1, kernel2}
 	layer.stride = [2]int{1, 1}
 	layer.padding = [2]int{0, 0}
-	layer.dilation = 1
+	layer.dilation = [2]int{1, 1}
 	layer.groups = 1
 	layer.w = layer.initW(int64(outC), int64(inC), int64(kernel1), int64(kernel2))
+	if layer.bias {
+		layer.b = layer.zeros(int64(outC))
+	}
 	return &layer
 }
 
@@ -39,12 +44,22 @@ func (layer *Conv2D) SetPadding(padding1, padding2 int) {
 	layer.padding = [2]int{padding1, padding2}
 }
 
-func (layer *Conv2D) SetDilation(dilation int) {
-	layer.dilation = dilation
+// SetSamePadding pads the input to keep the output size, only supports stride 1
+func (layer *Conv2D) SetSamePadding(same bool) {
+	layer.same = same
+}
+
+func (layer *Conv2D) SetDilation(dilation1, dilation2 int) {
+	layer.dilation = [2]int{dilation1, dilation2}
 }
 
+// SetGroups reallocates w to (outC, inC/groups, kernel...)
 func (layer *Conv2D) SetGroups(groups int) {
+	if groups <= 0 || layer.inC%groups != 0 || layer.outC%groups != 0 {
+		panic("inC and outC must be divisible by groups")
+	}
 	layer.groups = groups
+	layer.w = layer.initW(int64(layer.outC), int64(layer.inC/groups), int64(layer.kernel[0]), int64(layer.kernel[1]))
 }
 
 func LoadConv2D(name string, params []*tensor.Tensor, args map[string]float32) Layer {
@@ -55,53 +70,126 @@ func LoadConv2D(name string, params []*tensor.Tensor, args map[string]float32) L
 	layer.kernel = [2]int{int(args["kernel1"]), int(args["kernel2"])}
 	layer.stride = [2]int{int(args["stride1"]), int(args["stride2"])}
 	layer.padding = [2]int{int(args["padding1"]), int(args["padding2"])}
-	layer.dilation = int(args["dilation"])
+	layer.same = args["same_padding"] != 0
+	if _, ok := args["dilation1"]; ok {
+		layer.dilation = [2]int{int(args["dilation1"]), int(args["dilation2"])}
+	} else {
+		// compatible with the single dilation
+		layer.dilation = [2]int{int(args["dilation"]), int(args["dilation"])}
+	}
 	layer.groups = int(args["groups"])
 	layer.w = params[0]
+	if args["bias"] != 0 {
+		layer.b = params[1]
+	}
 	return &layer
 }
 
 func (layer *Conv2D) Forward(x *tensor.Tensor) *tensor.Tensor {
-	return x.Conv2D(layer.w, nil,
+	padding := layer.padding[:]
+	if layer.same {
+		x, padding = samePadding(x, layer.kernel[:], layer.stride[:], layer.dilation[:])
+	}
+	w := layer.w
+	dilation := layer.dilation[0]
+	if layer.dilation[0] != layer.dilation[1] {
+		// libtorch binding only accepts a single dilation, so dilate the kernel by hand
+		w = dilateKernel(w, 2, layer.dilation[0])
+		w = dilateKernel(w, 3, layer.dilation[1])
+		dilation = 1
+	}
+	return x.Conv2D(w, layer.b,
 		tensor.Conv2DStride(layer.stride[0], layer.stride[1]),
-		tensor.Conv2DPadding(layer.padding[0], layer.padding[1]),
-		tensor.Conv2DDilation(layer.dilation),
+		tensor.Conv2DPadding(padding[0], padding[1]),
+		tensor.Conv2DDilation(dilation),
 		tensor.Conv2DGroups(layer.groups))
 }
 
+func (layer *Conv2D) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
 func (layer *Conv2D) Params() []*tensor.Tensor {
+	if layer.b != nil {
+		return []*tensor.Tensor{
+			layer.w,
+			layer.b,
+		}
+	}
 	return []*tensor.Tensor{
 		layer.w,
 	}
 }
 
+func (layer *Conv2D) SetParams(params []*tensor.Tensor) {
+	dst := []**tensor.Tensor{&layer.w}
+	if layer.b != nil {
+		dst = append(dst, &layer.b)
+	}
+	setParams(params, dst...)
+}
+
+func (layer *Conv2D) ParamNames() []string {
+	if layer.b != nil {
+		return []string{
+			"w",
+			"b",
+		}
+	}
+	return []string{
+		"w",
+	}
+}
+
 func (layer *Conv2D) Args() map[string]float32 {
+	var bias, same float32
+	if layer.b != nil {
+		bias = 1
+	}
+	if layer.same {
+		same = 1
+	}
 	return map[string]float32{
-		"inC":      float32(layer.inC),
-		"outC":     float32(layer.outC),
-		"kernel1":  float32(layer.kernel[0]),
-		"kernel2":  float32(layer.kernel[1]),
-		"stride1":  float32(layer.stride[0]),
-		"stride2":  float32(layer.stride[1]),
-		"padding1": float32(layer.padding[0]),
-		"padding2": float32(layer.padding[1]),
-		"dilation": float32(layer.dilation),
-		"groups":   float32(layer.groups),
+		"inC":          float32(layer.inC),
+		"outC":         float32(layer.outC),
+		"kernel1":      float32(layer.kernel[0]),
+		"kernel2":      float32(layer.kernel[1]),
+		"stride1":      float32(layer.stride[0]),
+		"stride2":      float32(layer.stride[1]),
+		"padding1":     float32(layer.padding[0]),
+		"padding2":     float32(layer.padding[1]),
+		"same_padding": same,
+		"dilation1":    float32(layer.dilation[0]),
+		"dilation2":    float32(layer.dilation[1]),
+		"groups":       float32(layer.groups),
+		"bias":         bias,
 	}
 }
 
 func (layer *Conv2D) Freeze() {
 	layer.w.SetRequiresGrad(false)
+	if layer.b != nil {
+		layer.b.SetRequiresGrad(false)
+	}
 }
 
 func (layer *Conv2D) Unfreeze() {
 	layer.w.SetRequiresGrad(true)
+	if layer.b != nil {
+		layer.b.SetRequiresGrad(true)
+	}
 }
 
 func (layer *Conv2D) ToScalarType(t consts.ScalarType) {
 	layer.w = layer.w.ToScalarType(t)
+	if layer.b != nil {
+		layer.b = layer.b.ToScalarType(t)
+	}
 }
 
 func (layer *Conv2D) Reset() {
 	layer.w = layer.initW(layer.w.Shapes()...)
+	if layer.b != nil {
+		layer.b = layer.zeros(layer.b.Shapes()...)
+	}
 }
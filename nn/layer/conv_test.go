@@ -0,0 +1,74 @@
+package layer
+
+import (
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+func TestDilateKernel(t *testing.T) {
+	x := tensor.ARange(1*2*7*7, consts.KFloat).Reshape(1, 2, 7, 7)
+	l := NewConv2D("conv", 2, 3, 3, 3)
+	expect := x.Conv2D(l.w, l.b, tensor.Conv2DDilation(2)).Float32Value()
+	l.SetDilation(2, 2)
+	assertClose(t, "native dilation", l.Forward(x).Float32Value(), expect)
+	w := dilateKernel(dilateKernel(l.w, 2, 2), 3, 2)
+	assertShapes(t, "dilated kernel", w, 3, 2, 5, 5)
+	assertClose(t, "dilated kernel", x.Conv2D(w, l.b).Float32Value(), expect)
+
+	l.SetDilation(1, 3)
+	assertShapes(t, "per axis dilation", l.Forward(x), 1, 3, 5, 1)
+	loaded := LoadConv2D("conv", l.Params(), l.Args()).(*Conv2D)
+	assertClose(t, "per axis dilation", loaded.Forward(x).Float32Value(), l.Forward(x).Float32Value())
+}
+
+func TestSamePadding(t *testing.T) {
+	seq := tensor.ARange(1*2*6, consts.KFloat).Reshape(1, 2, 6)
+	conv1d := NewConv1D("conv", 2, 4, 4)
+	conv1d.SetSamePadding(true)
+	assertShapes(t, "conv1d", conv1d.Forward(seq), 1, 4, 6)
+
+	img := tensor.ARange(1*2*6*5, consts.KFloat).Reshape(1, 2, 6, 5)
+	conv2d := NewConv2D("conv", 2, 4, 3, 2)
+	conv2d.SetSamePadding(true)
+	conv2d.SetDilation(2, 1)
+	assertShapes(t, "conv2d", conv2d.Forward(img), 1, 4, 6, 5)
+	if LoadConv2D("conv", conv2d.Params(), conv2d.Args()).Args()["same_padding"] != 1 {
+		t.Fatal("same padding not loaded")
+	}
+
+	vol := tensor.ARange(1*2*4*4*4, consts.KFloat).Reshape(1, 2, 4, 4, 4)
+	conv3d := NewConv3D("conv", 2, 3, 3, 3, 2)
+	conv3d.SetSamePadding(true)
+	assertShapes(t, "conv3d", conv3d.Forward(vol), 1, 3, 4, 4, 4)
+}
+
+func TestConv3D(t *testing.T) {
+	x := tensor.ARange(1*2*4*4*4, consts.KFloat).Reshape(1, 2, 4, 4, 4)
+	conv := NewConv3D("conv", 2, 4, 3, 3, 3)
+	conv.SetGroups(2)
+	assertShapes(t, "grouped weight", conv.w, 4, 1, 3, 3, 3)
+	y := conv.Forward(x)
+	assertShapes(t, "conv3d", y, 1, 4, 2, 2, 2)
+	transpose := NewConvTranspose3D("deconv", 4, 2, 3, 3, 3)
+	transpose.SetStride(2, 2, 2)
+	transpose.SetOutputPadding(1, 1, 1)
+	assertShapes(t, "convtranspose3d", transpose.Forward(y), 1, 2, 6, 6, 6)
+}
+
+func TestSeparableConv2D(t *testing.T) {
+	x := tensor.ARange(2*3*5*5, consts.KFloat).Reshape(2, 3, 5, 5)
+	l := NewSeparableConv2D("conv", 3, 8, 3, 3)
+	l.SetPadding(1, 1)
+	names := l.ParamNames()
+	if len(names) != 4 || names[0] != "depthwise.w" || names[3] != "pointwise.b" {
+		t.Fatalf("unexpected param names: %v", names)
+	}
+	assertShapes(t, "depthwise weight", l.Params()[0], 3, 1, 3, 3)
+	assertShapes(t, "pointwise weight", l.Params()[2], 8, 3, 1, 1)
+	y := l.Forward(x)
+	assertShapes(t, "separable conv2d", y, 2, 8, 5, 5)
+	loaded := LoadSeparableConv2D("conv", l.Params(), l.Args()).(*SeparableConv2D)
+	assertClose(t, "separable conv2d", loaded.Forward(x).Float32Value(), y.Float32Value())
+}
@@ -16,6 +16,7 @@ type ConvTranspose1D struct {
 	groups        int
 	// params
 	w *tensor.Tensor
+	b *tensor.Tensor
 }
 
 func NewConvTranspose1D(name string, inC, outC, kernel int, opts ...LayerCreateOption) *ConvTranspose1D {
@@ -30,6 +31,9 @@ func NewConvTranspose1D(name string, inC, outC, kernel int, opts ...LayerCreateO
 	layer.dilation = 1
 	layer.groups = 1
 	layer.w = layer.initW(int64(inC), int64(outC), int64(kernel))
+	if layer.bias {
+		layer.b = layer.zeros(int64(outC))
+	}
 	return &layer
 }
 
@@ -49,8 +53,13 @@ func (layer *ConvTranspose1D) SetDilation(dilation int) {
 	layer.dilation = dilation
 }
 
+// SetGroups reallocates w to (inC, outC/groups, kernel...)
 func (layer *ConvTranspose1D) SetGroups(groups int) {
+	if groups <= 0 || layer.inC%groups != 0 || layer.outC%groups != 0 {
+		panic("inC and outC must be divisible by groups")
+	}
 	layer.groups = groups
+	layer.w = layer.initW(int64(layer.inC), int64(layer.outC/groups), int64(layer.kernel))
 }
 
 func LoadConvTranspose1D(name string, params []*tensor.Tensor, args map[string]float32) Layer {
@@ -65,11 +74,14 @@ func LoadConvTranspose1D(name string, params []*tensor.Tensor, args map[string]f
 	layer.dilation = int(args["dilation"])
 	layer.groups = int(args["groups"])
 	layer.w = params[0]
+	if args["bias"] != 0 {
+		layer.b = params[1]
+	}
 	return &layer
 }
 
 func (layer *ConvTranspose1D) Forward(x *tensor.Tensor) *tensor.Tensor {
-	return x.ConvTranspose1D(layer.w, nil,
+	return x.ConvTranspose1D(layer.w, layer.b,
 		tensor.ConvTranspose1DStride(layer.stride),
 		tensor.ConvTranspose1DPadding(layer.padding),
 		tensor.ConvTranspose1DOutputPadding(layer.outputPadding),
@@ -77,13 +89,47 @@ func (layer *ConvTranspose1D) Forward(x *tensor.Tensor) *tensor.Tensor {
 		tensor.ConvTranspose1DGroups(layer.groups))
 }
 
+func (layer *ConvTranspose1D) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
 func (layer *ConvTranspose1D) Params() []*tensor.Tensor {
+	if layer.b != nil {
+		return []*tensor.Tensor{
+			layer.w,
+			layer.b,
+		}
+	}
 	return []*tensor.Tensor{
 		layer.w,
 	}
 }
 
+func (layer *ConvTranspose1D) SetParams(params []*tensor.Tensor) {
+	dst := []**tensor.Tensor{&layer.w}
+	if layer.b != nil {
+		dst = append(dst, &layer.b)
+	}
+	setParams(params, dst...)
+}
+
+func (layer *ConvTranspose1D) ParamNames() []string {
+	if layer.b != nil {
+		return []string{
+			"w",
+			"b",
+		}
+	}
+	return []string{
+		"w",
+	}
+}
+
 func (layer *ConvTranspose1D) Args() map[string]float32 {
+	var bias float32
+	if layer.b != nil {
+		bias = 1
+	}
 	return map[string]float32{
 		"inC":            float32(layer.inC),
 		"outC":           float32(layer.outC),
@@ -93,21 +139,34 @@ func (layer *ConvTranspose1D) Args() map[string]float32 {
 		"output_padding": float32(layer.outputPadding),
 		"dilation":       float32(layer.dilation),
 		"groups":         float32(layer.groups),
+		"bias":           bias,
 	}
 }
 
 func (layer *ConvTranspose1D) Freeze() {
 	layer.w.SetRequiresGrad(false)
+	if layer.b != nil {
+		layer.b.SetRequiresGrad(false)
+	}
 }
 
 func (layer *ConvTranspose1D) Unfreeze() {
 	layer.w.SetRequiresGrad(true)
+	if layer.b != nil {
+		layer.b.SetRequiresGrad(true)
+	}
 }
 
 func (layer *ConvTranspose1D) ToScalarType(t consts.ScalarType) {
 	layer.w = layer.w.ToScalarType(t)
+	if layer.b != nil {
+		layer.b = layer.b.ToScalarType(t)
+	}
 }
 
 func (layer *ConvTranspose1D) Reset() {
 	layer.w = layer.initW(layer.w.Shapes()...)
+	if layer.b != nil {
+		layer.b = layer.zeros(layer.b.Shapes()...)
+	}
 }
@@ -16,6 +16,7 @@ type ConvTranspose2D struct {
 	groups        int
 	// params
 	w *tensor.Tensor
+	b *tensor.Tensor
 }
 
 func NewConvTranspose2D(name string, inC, outC int, kernel1, kernel2 int, opts ...LayerCreateOption) *ConvTranspose2D {
@@ -30,6 +31,9 @@ func NewConvTranspose2D(name string, inC, outC int, kernel1, kernel2 int, opts .
 	layer.dilation = 1
 	layer.groups = 1
 	layer.w = layer.initW(int64(inC), int64(outC), int64(kernel1), int64(kernel2))
+	if layer.bias {
+		layer.b = layer.zeros(int64(outC))
+	}
 	return &layer
 }
 
@@ -49,8 +53,13 @@ func (layer *ConvTranspose2D) SetDilation(dilation int) {
 	layer.dilation = dilation
 }
 
+// SetGroups reallocates w to (inC, outC/groups, kernel...)
 func (layer *ConvTranspose2D) SetGroups(groups int) {
+	if groups <= 0 || layer.inC%groups != 0 || layer.outC%groups != 0 {
+		panic("inC and outC must be divisible by groups")
+	}
 	layer.groups = groups
+	layer.w = layer.initW(int64(layer.inC), int64(layer.outC/groups), int64(layer.kernel[0]), int64(layer.kernel[1]))
 }
 
 func LoadConvTranspose2D(name string, params []*tensor.Tensor, args map[string]float32) Layer {
@@ -65,11 +74,14 @@ func LoadConvTranspose2D(name string, params []*tensor.Tensor, args map[string]f
 	layer.dilation = int(args["dilation"])
 	layer.groups = int(args["groups"])
 	layer.w = params[0]
+	if args["bias"] != 0 {
+		layer.b = params[1]
+	}
 	return &layer
 }
 
 func (layer *ConvTranspose2D) Forward(x *tensor.Tensor) *tensor.Tensor {
-	return x.ConvTranspose2D(layer.w, nil,
+	return x.ConvTranspose2D(layer.w, layer.b,
 		tensor.ConvTranspose2DStride(layer.stride[0], layer.stride[1]),
 		tensor.ConvTranspose2DPadding(layer.padding[0], layer.padding[1]),
 		tensor.ConvTranspose2DOutputPadding(layer.outputPadding[0], layer.outputPadding[1]),
@@ -77,13 +89,47 @@ func (layer *ConvTranspose2D) Forward(x *tensor.Tensor) *tensor.Tensor {
 		tensor.ConvTranspose2DGroups(layer.groups))
 }
 
+func (layer *ConvTranspose2D) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
 func (layer *ConvTranspose2D) Params() []*tensor.Tensor {
+	if layer.b != nil {
+		return []*tensor.Tensor{
+			layer.w,
+			layer.b,
+		}
+	}
 	return []*tensor.Tensor{
 		layer.w,
 	}
 }
 
+func (layer *ConvTranspose2D) SetParams(params []*tensor.Tensor) {
+	dst := []**tensor.Tensor{&layer.w}
+	if layer.b != nil {
+		dst = append(dst, &layer.b)
+	}
+	setParams(params, dst...)
+}
+
+func (layer *ConvTranspose2D) ParamNames() []string {
+	if layer.b != nil {
+		return []string{
+			"w",
+			"b",
+		}
+	}
+	return []string{
+		"w",
+	}
+}
+
 func (layer *ConvTranspose2D) Args() map[string]float32 {
+	var bias float32
+	if layer.b != nil {
+		bias = 1
+	}
 	return map[string]float32{
 		"inC":             float32(layer.inC),
 		"outC":            float32(layer.outC),
@@ -97,21 +143,34 @@ func (layer *ConvTranspose2D) Args() map[string]float32 {
 		"output_padding2": float32(layer.outputPadding[1]),
 		"dilation":        float32(layer.dilation),
 		"groups":          float32(layer.groups),
+		"bias":            bias,
 	}
 }
 
 func (layer *ConvTranspose2D) Freeze() {
 	layer.w.SetRequiresGrad(false)
+	if layer.b != nil {
+		layer.b.SetRequiresGrad(false)
+	}
 }
 
 func (layer *ConvTranspose2D) Unfreeze() {
 	layer.w.SetRequiresGrad(true)
+	if layer.b != nil {
+		layer.b.SetRequiresGrad(true)
+	}
 }
 
 func (layer *ConvTranspose2D) ToScalarType(t consts.ScalarType) {
 	layer.w = layer.w.ToScalarType(t)
+	if layer.b != nil {
+		layer.b = layer.b.ToScalarType(t)
+	}
 }
 
 func (layer *ConvTranspose2D) Reset() {
 	layer.w = layer.initW(layer.w.Shapes()...)
+	if layer.b != nil {
+		layer.b = layer.zeros(layer.b.Shapes()...)
+	}
 }
@@ -0,0 +1,180 @@
+package layer
+
+import (
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+type ConvTranspose3D struct {
+	base
+	inC, outC     int
+	kernel        [3]int
+	stride        [3]int
+	padding       [3]int
+	outputPadding [3]int
+	dilation      int
+	groups        int
+	// params
+	w *tensor.Tensor
+	b *tensor.Tensor
+}
+
+func NewConvTranspose3D(name string, inC, outC int, kernel1, kernel2, kernel3 int, opts ...LayerCreateOption) *ConvTranspose3D {
+	var layer ConvTranspose3D
+	layer.new("convtranspose3d", name, opts...)
+	layer.inC = inC
+	layer.outC = outC
+	layer.kernel = [3]int{kernel1, kernel2, kernel3}
+	layer.stride = [3]int{1, 1, 1}
+	layer.padding = [3]int{0, 0, 0}
+	layer.outputPadding = [3]int{0, 0, 0}
+	layer.dilation = 1
+	layer.groups = 1
+	layer.w = layer.initW(int64(inC), int64(outC), int64(kernel1), int64(kernel2), int64(kernel3))
+	if layer.bias {
+		layer.b = layer.zeros(int64(outC))
+	}
+	return &layer
+}
+
+func (layer *ConvTranspose3D) SetStride(stride1, stride2, stride3 int) {
+	layer.stride = [3]int{stride1, stride2, stride3}
+}
+
+func (layer *ConvTranspose3D) SetPadding(padding1, padding2, padding3 int) {
+	layer.padding = [3]int{padding1, padding2, padding3}
+}
+
+func (layer *ConvTranspose3D) SetOutputPadding(padding1, padding2, padding3 int) {
+	layer.outputPadding = [3]int{padding1, padding2, padding3}
+}
+
+func (layer *ConvTranspose3D) SetDilation(dilation int) {
+	layer.dilation = dilation
+}
+
+// SetGroups reallocates w to (inC, outC/groups, kernel...)
+func (layer *ConvTranspose3D) SetGroups(groups int) {
+	if groups <= 0 || layer.inC%groups != 0 || layer.outC%groups != 0 {
+		panic("inC and outC must be divisible by groups")
+	}
+	layer.groups = groups
+	layer.w = layer.initW(int64(layer.inC), int64(layer.outC/groups), int64(layer.kernel[0]), int64(layer.kernel[1]), int64(layer.kernel[2]))
+}
+
+func LoadConvTranspose3D(name string, params []*tensor.Tensor, args map[string]float32) Layer {
+	var layer ConvTranspose3D
+	layer.new("convtranspose3d", name)
+	layer.inC = int(args["inC"])
+	layer.outC = int(args["outC"])
+	layer.kernel = [3]int{int(args["kernel1"]), int(args["kernel2"]), int(args["kernel3"])}
+	layer.stride = [3]int{int(args["stride1"]), int(args["stride2"]), int(args["stride3"])}
+	layer.padding = [3]int{int(args["padding1"]), int(args["padding2"]), int(args["padding3"])}
+	layer.outputPadding = [3]int{int(args["output_padding1"]), int(args["output_padding2"]), int(args["output_padding3"])}
+	layer.dilation = int(args["dilation"])
+	layer.groups = int(args["groups"])
+	layer.w = params[0]
+	if args["bias"] != 0 {
+		layer.b = params[1]
+	}
+	return &layer
+}
+
+func (layer *ConvTranspose3D) Forward(x *tensor.Tensor) *tensor.Tensor {
+	return x.ConvTranspose3D(layer.w, layer.b,
+		tensor.ConvTranspose3DStride(layer.stride[0], layer.stride[1], layer.stride[2]),
+		tensor.ConvTranspose3DPadding(layer.padding[0], layer.padding[1], layer.padding[2]),
+		tensor.ConvTranspose3DOutputPadding(layer.outputPadding[0], layer.outputPadding[1], layer.outputPadding[2]),
+		tensor.ConvTranspose3DDilation(layer.dilation),
+		tensor.ConvTranspose3DGroups(layer.groups))
+}
+
+func (layer *ConvTranspose3D) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
+func (layer *ConvTranspose3D) Params() []*tensor.Tensor {
+	if layer.b != nil {
+		return []*tensor.Tensor{
+			layer.w,
+			layer.b,
+		}
+	}
+	return []*tensor.Tensor{
+		layer.w,
+	}
+}
+
+func (layer *ConvTranspose3D) SetParams(params []*tensor.Tensor) {
+	dst := []**tensor.Tensor{&layer.w}
+	if layer.b != nil {
+		dst = append(dst, &layer.b)
+	}
+	setParams(params, dst...)
+}
+
+func (layer *ConvTranspose3D) ParamNames() []string {
+	if layer.b != nil {
+		return []string{
+			"w",
+			"b",
+		}
+	}
+	return []string{
+		"w",
+	}
+}
+
+func (layer *ConvTranspose3D) Args() map[string]float32 {
+	var bias float32
+	if layer.b != nil {
+		bias = 1
+	}
+	return map[string]float32{
+		"inC":             float32(layer.inC),
+		"outC":            float32(layer.outC),
+		"kernel1":         float32(layer.kernel[0]),
+		"kernel2":         float32(layer.kernel[1]),
+		"kernel3":         float32(layer.kernel[2]),
+		"stride1":         float32(layer.stride[0]),
+		"stride2":         float32(layer.stride[1]),
+		"stride3":         float32(layer.stride[2]),
+		"padding1":        float32(layer.padding[0]),
+		"padding2":        float32(layer.padding[1]),
+		"padding3":        float32(layer.padding[2]),
+		"output_padding1": float32(layer.outputPadding[0]),
+		"output_padding2": float32(layer.outputPadding[1]),
+		"output_padding3": float32(layer.outputPadding[2]),
+		"dilation":        float32(layer.dilation),
+		"groups":          float32(layer.groups),
+		"bias":            bias,
+	}
+}
+
+func (layer *ConvTranspose3D) Freeze() {
+	layer.w.SetRequiresGrad(false)
+	if layer.b != nil {
+		layer.b.SetRequiresGrad(false)
+	}
+}
+
+func (layer *ConvTranspose3D) Unfreeze() {
+	layer.w.SetRequiresGrad(true)
+	if layer.b != nil {
+		layer.b.SetRequiresGrad(true)
+	}
+}
+
+func (layer *ConvTranspose3D) ToScalarType(t consts.ScalarType) {
+	layer.w = layer.w.ToScalarType(t)
+	if layer.b != nil {
+		layer.b = layer.b.ToScalarType(t)
+	}
+}
+
+func (layer *ConvTranspose3D) Reset() {
+	layer.w = layer.initW(layer.w.Shapes()...)
+	if layer.b != nil {
+		layer.b = layer.zeros(layer.b.Shapes()...)
+	}
+}
@@ -28,6 +28,10 @@ func (layer *Dropout) Forward(x *tensor.Tensor, train bool) *tensor.Tensor {
 	return x.Dropout(layer.keep, train)
 }
 
+func (layer *Dropout) Call(x *tensor.Tensor, train bool) *tensor.Tensor {
+	return layer.Forward(x, train)
+}
+
 func (layer *Dropout) Args() map[string]float32 {
 	return map[string]float32{
 		"keep": float32(layer.keep),
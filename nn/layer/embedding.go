@@ -42,12 +42,26 @@ func (layer *Embedding) Forward(x *tensor.Tensor) *tensor.Tensor {
 	return tensor.Embedding(x, layer.w, layer.padding)
 }
 
+func (layer *Embedding) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
 func (layer *Embedding) Params() []*tensor.Tensor {
 	return []*tensor.Tensor{
 		layer.w,
 	}
 }
 
+func (layer *Embedding) SetParams(params []*tensor.Tensor) {
+	setParams(params, &layer.w)
+}
+
+func (layer *Embedding) ParamNames() []string {
+	return []string{
+		"w",
+	}
+}
+
 func (layer *Embedding) Args() map[string]float32 {
 	return map[string]float32{
 		"num":     float32(layer.num),
@@ -0,0 +1,227 @@
+package layer
+
+import (
+	"fmt"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+// FeedForwardActivation is the activation between the two projections of FeedForward
+type FeedForwardActivation int
+
+const (
+	FeedForwardReLU FeedForwardActivation = iota
+	FeedForwardGeLU
+	FeedForwardSiLU
+	FeedForwardSigmoid
+	// FeedForwardSwiGLU computes silu(x*w1) * (x*w3) as the hidden states
+	FeedForwardSwiGLU
+	// FeedForwardGeGLU computes gelu(x*w1) * (x*w3) as the hidden states
+	FeedForwardGeGLU
+)
+
+func (act FeedForwardActivation) gated() bool {
+	return act == FeedForwardSwiGLU || act == FeedForwardGeGLU
+}
+
+// FeedForward is the position-wise feed forward block of transformer,
+// it computes w2 * dropout(act(w1 * x)), the gated activations multiply act(w1 * x) by w3 * x
+type FeedForward struct {
+	base
+	dims       int
+	hidden     int
+	activation FeedForwardActivation
+	dropout    float64
+	// params
+	w1, w2, w3 *tensor.Tensor
+	b1, b2, b3 *tensor.Tensor
+}
+
+func NewFeedForward(name string, dims int, opts ...LayerCreateOption) *FeedForward {
+	var layer FeedForward
+	layer.new("feed_forward", name, opts...)
+	layer.dims = dims
+	layer.hidden = dims * 4
+	layer.activation = FeedForwardReLU
+	layer.alloc()
+	return &layer
+}
+
+// SetHiddenRatio reallocates the params with dims*ratio hidden units, default is 4,
+// LLaMA uses 8/3 for the gated activations to keep the params count
+func (layer *FeedForward) SetHiddenRatio(ratio float64) {
+	hidden := int(float64(layer.dims) * ratio)
+	if hidden <= 0 {
+		panic(fmt.Errorf("invalid hidden ratio: %f", ratio))
+	}
+	layer.hidden = hidden
+	layer.alloc()
+}
+
+// SetActivation changes the activation, the params are reallocated when switching
+// between gated and non-gated activations
+func (layer *FeedForward) SetActivation(act FeedForwardActivation) {
+	switch act {
+	case FeedForwardReLU, FeedForwardGeLU, FeedForwardSiLU, FeedForwardSigmoid,
+		FeedForwardSwiGLU, FeedForwardGeGLU:
+	default:
+		panic(fmt.Errorf("unsupported activation: %d", act))
+	}
+	gated := layer.activation.gated()
+	layer.activation = act
+	if gated != act.gated() {
+		layer.alloc()
+	}
+}
+
+// SetDropout sets the dropout probability of the hidden states
+func (layer *FeedForward) SetDropout(dropout float64) {
+	layer.dropout = dropout
+}
+
+func (layer *FeedForward) alloc() {
+	layer.w1 = layer.initW(int64(layer.hidden), int64(layer.dims))
+	layer.w2 = layer.initW(int64(layer.dims), int64(layer.hidden))
+	layer.w3 = nil
+	if layer.activation.gated() {
+		layer.w3 = layer.initW(int64(layer.hidden), int64(layer.dims))
+	}
+	layer.b1, layer.b2, layer.b3 = nil, nil, nil
+	if layer.bias {
+		layer.b1 = layer.zeros(int64(layer.hidden))
+		layer.b2 = layer.zeros(int64(layer.dims))
+		if layer.w3 != nil {
+			layer.b3 = layer.zeros(int64(layer.hidden))
+		}
+	}
+}
+
+func LoadFeedForward(name string, params []*tensor.Tensor, args map[string]float32) Layer {
+	var layer FeedForward
+	layer.new("feed_forward", name)
+	layer.dims = int(args["dims"])
+	layer.hidden = int(args["hidden"])
+	layer.activation = FeedForwardActivation(args["activation"])
+	layer.dropout = float64(args["dropout"])
+	layer.bias = args["bias"] != 0
+	next := func() *tensor.Tensor {
+		p := params[0]
+		params = params[1:]
+		return p
+	}
+	layer.w1 = next()
+	layer.w2 = next()
+	if layer.activation.gated() {
+		layer.w3 = next()
+	}
+	if layer.bias {
+		layer.b1 = next()
+		layer.b2 = next()
+		if layer.w3 != nil {
+			layer.b3 = next()
+		}
+	}
+	return &layer
+}
+
+func (layer *FeedForward) Forward(x *tensor.Tensor, train bool) *tensor.Tensor {
+	y := linear(x, layer.w1, layer.b1)
+	switch layer.activation {
+	case FeedForwardReLU:
+		y = y.Relu()
+	case FeedForwardGeLU, FeedForwardGeGLU:
+		y = y.Gelu(false)
+	case FeedForwardSiLU, FeedForwardSwiGLU:
+		y = y.Silu()
+	case FeedForwardSigmoid:
+		y = y.Sigmoid()
+	}
+	if layer.w3 != nil {
+		y = y.Mul(linear(x, layer.w3, layer.b3))
+	}
+	if layer.dropout > 0 {
+		y = y.Dropout(layer.dropout, train)
+	}
+	return linear(y, layer.w2, layer.b2)
+}
+
+func (layer *FeedForward) Call(x *tensor.Tensor, train bool) *tensor.Tensor {
+	return layer.Forward(x, train)
+}
+
+func (layer *FeedForward) params() []**tensor.Tensor {
+	ret := []**tensor.Tensor{&layer.w1, &layer.w2}
+	if layer.w3 != nil {
+		ret = append(ret, &layer.w3)
+	}
+	if layer.b1 != nil {
+		ret = append(ret, &layer.b1, &layer.b2)
+		if layer.b3 != nil {
+			ret = append(ret, &layer.b3)
+		}
+	}
+	return ret
+}
+
+func (layer *FeedForward) Params() []*tensor.Tensor {
+	var ret []*tensor.Tensor
+	for _, p := range layer.params() {
+		ret = append(ret, *p)
+	}
+	return ret
+}
+
+func (layer *FeedForward) SetParams(params []*tensor.Tensor) {
+	setParams(params, layer.params()...)
+}
+
+func (layer *FeedForward) ParamNames() []string {
+	ret := []string{"w1", "w2"}
+	if layer.w3 != nil {
+		ret = append(ret, "w3")
+	}
+	if layer.b1 != nil {
+		ret = append(ret, "b1", "b2")
+		if layer.b3 != nil {
+			ret = append(ret, "b3")
+		}
+	}
+	return ret
+}
+
+func (layer *FeedForward) Args() map[string]float32 {
+	var bias float32
+	if layer.b1 != nil {
+		bias = 1
+	}
+	return map[string]float32{
+		"dims":       float32(layer.dims),
+		"hidden":     float32(layer.hidden),
+		"activation": float32(layer.activation),
+		"dropout":    float32(layer.dropout),
+		"bias":       bias,
+	}
+}
+
+func (layer *FeedForward) Freeze() {
+	for _, p := range layer.Params() {
+		p.SetRequiresGrad(false)
+	}
+}
+
+func (layer *FeedForward) Unfreeze() {
+	for _, p := range layer.Params() {
+		p.SetRequiresGrad(true)
+	}
+}
+
+func (layer *FeedForward) ToScalarType(t consts.ScalarType) {
+	for _, p := range layer.params() {
+		*p = (*p).ToScalarType(t)
+	}
+}
+
+func (layer *FeedForward) Reset() {
+	layer.alloc()
+}
@@ -0,0 +1,37 @@
+package layer
+
+import (
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+func TestFeedForward(t *testing.T) {
+	x := tensor.ARange(2*3*4, consts.KFloat).Reshape(2, 3, 4).Div(
+		tensor.FromFloat32([]float32{24}, tensor.WithShapes(1)))
+	l := NewFeedForward("ffn", 4)
+	assertShapes(t, "w1", l.Params()[0], 16, 4)
+	assertShapes(t, "relu", l.Forward(x, false), 2, 3, 4)
+
+	l = NewFeedForward("ffn", 4, WithBias(false))
+	l.SetActivation(FeedForwardSwiGLU)
+	l.SetHiddenRatio(2)
+	names := l.ParamNames()
+	if len(names) != 3 || names[2] != "w3" {
+		t.Fatalf("unexpected param names: %v", names)
+	}
+	assertShapes(t, "w3", l.Params()[2], 8, 4)
+	// silu(x*w1) * (x*w3) * w2
+	h := linear(x, l.w1, nil).Silu().Mul(linear(x, l.w3, nil))
+	expect := linear(h, l.w2, nil).Float32Value()
+	y := l.Forward(x, false).Float32Value()
+	assertClose(t, "swiglu", y, expect)
+	loaded := LoadFeedForward("ffn", l.Params(), l.Args()).(*FeedForward)
+	assertClose(t, "swiglu", loaded.Forward(x, false).Float32Value(), y)
+
+	l.SetActivation(FeedForwardGeLU)
+	if len(l.Params()) != 2 {
+		t.Fatalf("unexpected params count: %d", len(l.Params()))
+	}
+}
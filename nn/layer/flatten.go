@@ -30,6 +30,10 @@ func (layer *Flatten) Forward(x *tensor.Tensor) *tensor.Tensor {
 	return x.Reshape(shape[0], cols)
 }
 
+func (layer *Flatten) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
 func (layer *Flatten) ToScalarType(t consts.ScalarType) {
 }
 
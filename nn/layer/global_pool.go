@@ -0,0 +1,59 @@
+package layer
+
+import (
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+// globalPooling reduces all spatial dims of x (batch, channels, ...) to (batch, channels)
+type globalPooling struct {
+	base
+	max bool
+}
+
+func (p *globalPooling) Forward(x *tensor.Tensor) *tensor.Tensor {
+	y := x.Flatten(2, -1) // (batch, channels, spatial)
+	if p.max {
+		return y.Max(-1, false)
+	}
+	return y.Mean(-1, false)
+}
+
+func (p *globalPooling) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return p.Forward(x)
+}
+
+func (p *globalPooling) ToScalarType(t consts.ScalarType) {
+}
+
+func (p *globalPooling) Reset() {
+}
+
+type GlobalAvgPool struct {
+	globalPooling
+}
+
+func NewGlobalAvgPool(name string) *GlobalAvgPool {
+	var layer GlobalAvgPool
+	layer.new("global_avgpool", name)
+	return &layer
+}
+
+func LoadGlobalAvgPool(name string, _ []*tensor.Tensor, _ map[string]float32) Layer {
+	return NewGlobalAvgPool(name)
+}
+
+type GlobalMaxPool struct {
+	globalPooling
+}
+
+func NewGlobalMaxPool(name string) *GlobalMaxPool {
+	var layer GlobalMaxPool
+	layer.new("global_maxpool", name)
+	layer.max = true
+	return &layer
+}
+
+func LoadGlobalMaxPool(name string, _ []*tensor.Tensor, _ map[string]float32) Layer {
+	return NewGlobalMaxPool(name)
+}
@@ -0,0 +1,48 @@
+package layer
+
+import (
+	"github.com/lwch/gotorch/tensor"
+)
+
+// GroupNorm divides the channels of x (batch, channels, ...) into groups and normalizes
+// each group of each sample
+type GroupNorm struct {
+	channelNorm
+	groups int64
+}
+
+func NewGroupNorm(name string, groups, channels int64, opts ...LayerCreateOption) *GroupNorm {
+	if channels%groups != 0 {
+		panic("channels must be divisible by groups")
+	}
+	var layer GroupNorm
+	layer.init("group_norm", name, channels, true, opts...)
+	layer.groups = groups
+	return &layer
+}
+
+func LoadGroupNorm(name string, params []*tensor.Tensor, args map[string]float32) Layer {
+	var layer GroupNorm
+	layer.load("group_norm", name, params, args)
+	layer.groups = int64(args["groups"])
+	return &layer
+}
+
+func (layer *GroupNorm) Forward(x *tensor.Tensor) *tensor.Tensor {
+	shapes := x.Shapes()
+	y := x.Reshape(shapes[0], layer.groups, -1) // (batch, groups, channels/groups*...)
+	mean := y.Mean(-1, true)
+	v := y.Var(-1, false, true)
+	y = y.Sub(mean).Div(v.Add(layer.epsT.ToDevice(x.DeviceType())).Sqrt())
+	return layer.affine(y.Reshape(shapes...))
+}
+
+func (layer *GroupNorm) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
+func (layer *GroupNorm) Args() map[string]float32 {
+	ret := layer.args()
+	ret["groups"] = float32(layer.groups)
+	return ret
+}
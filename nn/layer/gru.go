@@ -0,0 +1,92 @@
+package layer
+
+import (
+	"github.com/lwch/gotorch/tensor"
+)
+
+type Gru struct {
+	recurrent
+}
+
+func newGruCell(r *recurrent, input int64) []*tensor.Tensor {
+	return []*tensor.Tensor{
+		r.initW(input+int64(r.hidden), int64(r.hidden)), // wr
+		r.initW(input+int64(r.hidden), int64(r.hidden)), // wz
+		r.initW(input, int64(r.hidden)),                 // wxn
+		r.initW(int64(r.hidden), int64(r.hidden)),       // whn
+		r.initB(int64(r.hidden)),                        // br
+		r.initB(int64(r.hidden)),                        // bz
+		r.initB(int64(r.hidden)),                        // bxn
+		r.initB(int64(r.hidden)),                        // bhn
+	}
+}
+
+// gruCell is the same as PyTorch:
+//
+//	r = sigmoid(x*Wir + h*Whr + br)
+//	z = sigmoid(x*Wiz + h*Whz + bz)
+//	n = tanh(x*Wxn + bxn + r*(h*Whn + bhn))
+//	h = (1-z)*n + z*h
+func gruCell(params []*tensor.Tensor, x *tensor.Tensor, states []*tensor.Tensor) []*tensor.Tensor {
+	h := states[0]
+	xh := tensor.HStack(x, h)                                   // (batch, feature+hidden)
+	r := xh.MatMul(params[0]).Add(params[4]).Sigmoid()          // (batch, hidden)
+	z := xh.MatMul(params[1]).Add(params[5]).Sigmoid()          // (batch, hidden)
+	n := x.MatMul(params[2]).Add(params[6])                     // (batch, hidden)
+	n = n.Add(r.Mul(h.MatMul(params[3]).Add(params[7]))).Tanh() // (batch, hidden)
+	h = n.Add(z.Mul(h.Sub(n)))                                  // (batch, hidden)
+	return []*tensor.Tensor{h}
+}
+
+var gruParamNames = []string{"wr", "wz", "wxn", "whn", "br", "bz", "bxn", "bhn"}
+
+// NewGru creates gru layer, steps is only saved in Args,
+// Forward infers the count of steps from the input
+func NewGru(name string, featureSize, steps, hidden int, opts ...LayerCreateOption) *Gru {
+	var layer Gru
+	layer.new("gru", name, opts...)
+	layer.setup(featureSize, steps, hidden, gruParamNames, newGruCell)
+	return &layer
+}
+
+func LoadGru(name string, params []*tensor.Tensor, args map[string]float32) Layer {
+	var layer Gru
+	layer.new("gru", name)
+	layer.load(params, args, gruParamNames, newGruCell)
+	return &layer
+}
+
+// Forward runs without the dropout between layers, use ForwardMask to train with dropout
+func (layer *Gru) Forward(x, h *tensor.Tensor) (*tensor.Tensor, *tensor.Tensor) {
+	return layer.ForwardMask(x, nil, h, false)
+}
+
+// ForwardLengths runs the first lengths[i] steps of sample i, see ForwardMask
+func (layer *Gru) ForwardLengths(x *tensor.Tensor, lengths []int64, h *tensor.Tensor, train bool) (*tensor.Tensor, *tensor.Tensor) {
+	return layer.ForwardMask(x, lengthMask(x, lengths), h, train)
+}
+
+// ForwardMask runs x (batch, steps, feature), see Rnn.ForwardMask
+func (layer *Gru) ForwardMask(x, mask, h *tensor.Tensor, train bool) (*tensor.Tensor, *tensor.Tensor) {
+	y, states := layer.forward(x, mask, []*tensor.Tensor{h}, train, gruCell)
+	return y, states[0]
+}
+
+func (layer *Gru) Call(x *tensor.Tensor, train bool) *tensor.Tensor {
+	y, _ := layer.ForwardMask(x, nil, nil, train)
+	return y
+}
+
+// CallMulti inputs are x, an optional hidden state and an optional mask (batch, steps),
+// outputs are y and hidden state
+func (layer *Gru) CallMulti(train bool, xs ...*tensor.Tensor) []*tensor.Tensor {
+	var h, mask *tensor.Tensor
+	if len(xs) > 1 {
+		h = xs[1]
+	}
+	if len(xs) > 2 {
+		mask = xs[2]
+	}
+	y, h := layer.ForwardMask(xs[0], mask, h, train)
+	return []*tensor.Tensor{y, h}
+}
@@ -0,0 +1,39 @@
+package layer
+
+import (
+	"github.com/lwch/gotorch/tensor"
+)
+
+// InstanceNorm normalizes each channel of each sample of x (batch, channels, ...),
+// it has no learnable scale and bias by default
+type InstanceNorm struct {
+	channelNorm
+}
+
+func NewInstanceNorm(name string, channels int64, opts ...LayerCreateOption) *InstanceNorm {
+	var layer InstanceNorm
+	layer.init("instance_norm", name, channels, false, opts...)
+	return &layer
+}
+
+func LoadInstanceNorm(name string, params []*tensor.Tensor, args map[string]float32) Layer {
+	var layer InstanceNorm
+	layer.load("instance_norm", name, params, args)
+	return &layer
+}
+
+func (layer *InstanceNorm) Forward(x *tensor.Tensor) *tensor.Tensor {
+	shapes := x.Shapes()
+	y := x.Reshape(shapes[0], shapes[1], -1) // (batch, channels, ...)
+	mean := y.Mean(-1, true)
+	v := y.Var(-1, false, true)
+	return layer.normalize(y, mean, v).Reshape(shapes...)
+}
+
+func (layer *InstanceNorm) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
+func (layer *InstanceNorm) Args() map[string]float32 {
+	return layer.args()
+}
@@ -0,0 +1,61 @@
+package layer
+
+import (
+	"github.com/lwch/gotorch/tensor"
+)
+
+// KVCache keeps the keys and values of the previous tokens for incremental decoding,
+// each attention layer needs its own cache
+type KVCache struct {
+	k, v *tensor.Tensor // (batch, heads, seq, dims/heads)
+}
+
+func NewKVCache() *KVCache {
+	return &KVCache{}
+}
+
+// Len returns the count of cached tokens, it is the position of the next token
+func (c *KVCache) Len() int64 {
+	if c.k == nil {
+		return 0
+	}
+	return c.k.Shapes()[2]
+}
+
+// Reset drops all cached tokens
+func (c *KVCache) Reset() {
+	c.k = nil
+	c.v = nil
+}
+
+// append appends k and v (batch, heads, seq, dims/heads) and returns all cached keys and values
+func (c *KVCache) append(k, v *tensor.Tensor) (*tensor.Tensor, *tensor.Tensor) {
+	if c.k == nil {
+		c.k, c.v = k, v
+	} else {
+		c.k = tensor.Cat([]*tensor.Tensor{c.k, k}, 2)
+		c.v = tensor.Cat([]*tensor.Tensor{c.v, v}, 2)
+	}
+	return c.k, c.v
+}
+
+// Select keeps the cached tokens of the samples in idx order, a sample can be selected more than once,
+// e.g. beam search follows the parent of each beam
+func (c *KVCache) Select(idx []int64) {
+	if c.k == nil {
+		return
+	}
+	index := tensor.FromInt64(idx,
+		tensor.WithShapes(int64(len(idx))),
+		tensor.WithDevice(c.k.DeviceType()))
+	c.k = selectRows(c.k, index)
+	c.v = selectRows(c.v, index)
+}
+
+// selectRows selects the rows of x on the first dim, gotorch has no index_select
+// so it is done by embedding lookup on the flattened rows
+func selectRows(x, index *tensor.Tensor) *tensor.Tensor {
+	shapes := x.Shapes()
+	y := tensor.Embedding(index, x.Reshape(shapes[0], -1), -1)
+	return y.Reshape(append([]int64{index.Shapes()[0]}, shapes[1:]...)...)
+}
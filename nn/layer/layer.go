@@ -12,8 +12,13 @@ import (
 
 type Layer interface {
 	Params() []*tensor.Tensor
+	// ParamNames returns the name of each param in Params order
+	ParamNames() []string
+	// SetParams replaces the params by the given ones in Params order
+	SetParams(params []*tensor.Tensor)
 	Class() string
 	Name() string
+	SetName(name string)
 	Args() map[string]float32
 	Freeze()
 	Unfreeze()
@@ -21,12 +26,36 @@ type Layer interface {
 	Reset()
 }
 
+// BufferLayer is a layer with non-trainable tensors, e.g. the running statistics of BatchNorm,
+// they are saved in the checkpoint but not returned by Params
+type BufferLayer interface {
+	Layer
+	Buffers() []*tensor.Tensor
+	// BufferNames returns the name of each buffer in Buffers order
+	BufferNames() []string
+	// SetBuffers replaces the buffers by the loaded ones in Buffers order
+	SetBuffers(buffers []*tensor.Tensor)
+}
+
+// Module is a layer which can be chained by a single input tensor
+type Module interface {
+	Layer
+	Call(x *tensor.Tensor, train bool) *tensor.Tensor
+}
+
+// MultiModule is a layer which takes more than one input tensor
+type MultiModule interface {
+	Layer
+	CallMulti(train bool, xs ...*tensor.Tensor) []*tensor.Tensor
+}
+
 type base struct {
 	init      initializer.Initializer
 	name      string
 	class     string
 	device    consts.DeviceType
 	paramType consts.ScalarType
+	bias      bool
 }
 
 type LayerCreateOption func(*base)
@@ -49,12 +78,20 @@ func WithParamType(t consts.ScalarType) LayerCreateOption {
 	}
 }
 
+// WithBias enable or disable the bias of Linear and convolution layers, default is enabled
+func WithBias(bias bool) LayerCreateOption {
+	return func(b *base) {
+		b.bias = bias
+	}
+}
+
 func (b *base) new(class, name string, opts ...LayerCreateOption) {
 	b.class = class
 	b.name = name
 	b.device = consts.KCPU
 	b.init = initializer.NewXavierUniform(1)
 	b.paramType = consts.KFloat
+	b.bias = true
 	for _, opt := range opts {
 		opt(b)
 	}
@@ -77,10 +114,32 @@ func (b *base) Name() string {
 	return b.name
 }
 
+func (b *base) SetName(name string) {
+	b.name = name
+}
+
 func (b *base) Params() []*tensor.Tensor {
 	return nil
 }
 
+func (b *base) ParamNames() []string {
+	return nil
+}
+
+func (b *base) SetParams(params []*tensor.Tensor) {
+	setParams(params)
+}
+
+// setParams assigns params to dst in order, it panics when the count mismatches
+func setParams(params []*tensor.Tensor, dst ...**tensor.Tensor) {
+	if len(params) != len(dst) {
+		panic(fmt.Errorf("expected %d params, got %d", len(dst), len(params)))
+	}
+	for i, p := range params {
+		*dst[i] = p
+	}
+}
+
 func (b *base) Args() map[string]float32 {
 	return nil
 }
@@ -153,6 +212,14 @@ func (b *base) initN(n float64) *tensor.Tensor {
 	}
 }
 
+func (b *base) zeros(shapes ...int64) *tensor.Tensor {
+	t := tensor.Zeros(b.paramType,
+		tensor.WithDevice(b.device),
+		tensor.WithShapes(shapes...))
+	t.SetRequiresGrad(true)
+	return t
+}
+
 func (b *base) ones(shapes ...int64) *tensor.Tensor {
 	n := shapes[0]
 	for i := 1; i < len(shapes); i++ {
@@ -1,62 +1,196 @@
 package layer
 
 import (
+	"fmt"
+
 	"github.com/lwch/gotorch/consts"
 	"github.com/lwch/gotorch/tensor"
 )
 
 type LayerNorm struct {
 	base
-	eps *tensor.Tensor
+	shape []int64
+	eps   float64
+	// runtime
+	epsT *tensor.Tensor
 	// params
 	a *tensor.Tensor
+	b *tensor.Tensor
 }
 
 func NewLayerNorm(name string, dims int64, opts ...LayerCreateOption) *LayerNorm {
+	return NewLayerNormWithShape(name, []int64{dims}, opts...)
+}
+
+// NewLayerNormWithShape normalize the input over the last len(shape) dims,
+// eps default is 1e-5, it has a learnable scale and bias by default
+func NewLayerNormWithShape(name string, shape []int64, opts ...LayerCreateOption) *LayerNorm {
 	var layer LayerNorm
 	layer.new("layer_norm", name, opts...)
-	layer.eps = layer.initN(1e-9)
-	layer.a = layer.ones(dims)
+	layer.shape = append([]int64(nil), shape...)
+	layer.eps = 1e-5
+	layer.epsT = layer.initN(layer.eps)
+	layer.a = layer.ones(shape...)
 	layer.a.SetRequiresGrad(true)
+	if layer.bias {
+		layer.b = layer.zeros(shape...)
+	}
 	return &layer
 }
 
 func LoadLayerNorm(name string, params []*tensor.Tensor, args map[string]float32) Layer {
 	var layer LayerNorm
 	layer.new("layer_norm", name)
-	layer.eps = layer.initN(1e-9)
-	layer.a = params[0]
+	// checkpoints without eps use the old hard-coded value
+	layer.eps = 1e-9
+	if eps, ok := args["eps"]; ok {
+		layer.eps = float64(eps)
+	}
+	affine := true
+	if v, ok := args["affine"]; ok {
+		affine = v != 0
+	}
+	if affine {
+		layer.paramType = params[0].ScalarType()
+		layer.a = params[0]
+		if args["bias"] != 0 {
+			layer.b = params[1]
+		}
+	}
+	if n := int(args["shape_size"]); n > 0 {
+		layer.shape = make([]int64, n)
+		for i := 0; i < n; i++ {
+			layer.shape[i] = int64(args[fmt.Sprintf("shape_%d", i)])
+		}
+	} else {
+		layer.shape = layer.a.Shapes()
+	}
+	layer.epsT = layer.initN(layer.eps)
 	return &layer
 }
 
+func (layer *LayerNorm) SetEps(eps float64) {
+	layer.eps = eps
+	layer.epsT = layer.initN(eps)
+}
+
+// SetElementwiseAffine disable the learnable scale and bias when affine is false
+func (layer *LayerNorm) SetElementwiseAffine(affine bool) {
+	if !affine {
+		layer.a = nil
+		layer.b = nil
+		return
+	}
+	if layer.a == nil {
+		layer.a = layer.ones(layer.shape...)
+		layer.a.SetRequiresGrad(true)
+	}
+	if layer.b == nil && layer.bias {
+		layer.b = layer.zeros(layer.shape...)
+	}
+}
+
 func (layer *LayerNorm) Forward(x *tensor.Tensor) *tensor.Tensor {
-	mean := x.Mean(-1, true)
-	v := x.Var(-1, false, true)
-	sub := x.Sub(mean)
-	bias := v.Add(layer.eps).Sqrt()
-	div := sub.Div(bias)
-	return div.Mul(layer.a)
+	inputShape := x.Shapes()
+	y := x.Flatten(x.Dims()-int64(len(layer.shape)), -1)
+	mean := y.Mean(-1, true)
+	v := y.Var(-1, false, true)
+	sub := y.Sub(mean)
+	std := v.Add(layer.epsT.ToDevice(x.DeviceType())).Sqrt()
+	y = sub.Div(std).Reshape(inputShape...)
+	if layer.a != nil {
+		y = y.Mul(layer.a)
+	}
+	if layer.b != nil {
+		y = y.Add(layer.b)
+	}
+	return y
+}
+
+func (layer *LayerNorm) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
 }
 
 func (layer *LayerNorm) Params() []*tensor.Tensor {
-	return []*tensor.Tensor{
-		layer.a,
+	var ret []*tensor.Tensor
+	if layer.a != nil {
+		ret = append(ret, layer.a)
+	}
+	if layer.b != nil {
+		ret = append(ret, layer.b)
+	}
+	return ret
+}
+
+func (layer *LayerNorm) SetParams(params []*tensor.Tensor) {
+	var dst []**tensor.Tensor
+	if layer.a != nil {
+		dst = append(dst, &layer.a)
+	}
+	if layer.b != nil {
+		dst = append(dst, &layer.b)
 	}
+	setParams(params, dst...)
+}
+
+func (layer *LayerNorm) ParamNames() []string {
+	var ret []string
+	if layer.a != nil {
+		ret = append(ret, "a")
+	}
+	if layer.b != nil {
+		ret = append(ret, "b")
+	}
+	return ret
+}
+
+func (layer *LayerNorm) Args() map[string]float32 {
+	var affine, bias float32
+	if layer.a != nil {
+		affine = 1
+	}
+	if layer.b != nil {
+		bias = 1
+	}
+	ret := map[string]float32{
+		"eps":        float32(layer.eps),
+		"affine":     affine,
+		"bias":       bias,
+		"shape_size": float32(len(layer.shape)),
+	}
+	for i, s := range layer.shape {
+		ret[fmt.Sprintf("shape_%d", i)] = float32(s)
+	}
+	return ret
 }
 
 func (layer *LayerNorm) Freeze() {
-	layer.a.SetRequiresGrad(false)
+	for _, p := range layer.Params() {
+		p.SetRequiresGrad(false)
+	}
 }
 
 func (layer *LayerNorm) Unfreeze() {
-	layer.a.SetRequiresGrad(true)
+	for _, p := range layer.Params() {
+		p.SetRequiresGrad(true)
+	}
 }
 
 func (layer *LayerNorm) ToScalarType(t consts.ScalarType) {
-	layer.a = layer.a.ToScalarType(t)
+	if layer.a != nil {
+		layer.a = layer.a.ToScalarType(t)
+	}
+	if layer.b != nil {
+		layer.b = layer.b.ToScalarType(t)
+	}
 }
 
 func (layer *LayerNorm) Reset() {
-	layer.a = layer.ones(layer.a.Shapes()...)
-	layer.a.SetRequiresGrad(true)
+	if layer.a != nil {
+		layer.a = layer.ones(layer.a.Shapes()...)
+		layer.a.SetRequiresGrad(true)
+	}
+	if layer.b != nil {
+		layer.b = layer.zeros(layer.b.Shapes()...)
+	}
 }
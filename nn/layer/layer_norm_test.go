@@ -0,0 +1,33 @@
+package layer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+func TestLayerNormShape(t *testing.T) {
+	l := NewLayerNormWithShape("norm", []int64{3, 4})
+	x := tensor.ARange(2*3*4, consts.KFloat).Reshape(2, 3, 4)
+	y := l.Forward(x)
+	shapes := y.Shapes()
+	if len(shapes) != 3 || shapes[0] != 2 || shapes[1] != 3 || shapes[2] != 4 {
+		t.Fatalf("unexpected shapes: %v", shapes)
+	}
+	values := y.Float32Value()
+	for b := 0; b < 2; b++ {
+		var sum float64
+		for _, v := range values[b*12 : (b+1)*12] {
+			sum += float64(v)
+		}
+		if math.Abs(sum) > 1e-4 {
+			t.Fatalf("unexpected mean of batch %d: %f", b, sum/12)
+		}
+	}
+	loaded := LoadLayerNorm("norm", l.Params(), l.Args()).(*LayerNorm)
+	if float32(loaded.eps) != float32(l.eps) || len(loaded.shape) != 2 || loaded.b == nil {
+		t.Fatal("unexpected loaded layer")
+	}
+}
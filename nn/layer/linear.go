@@ -10,6 +10,7 @@ type Linear struct {
 	output int
 	// params
 	w *tensor.Tensor
+	b *tensor.Tensor
 }
 
 func NewLinear(name string, input, output int, opts ...LayerCreateOption) *Linear {
@@ -17,6 +18,9 @@ func NewLinear(name string, input, output int, opts ...LayerCreateOption) *Linea
 	layer.new("linear", name, opts...)
 	layer.output = output
 	layer.w = layer.initW(int64(layer.output), int64(input))
+	if layer.bias {
+		layer.b = layer.zeros(int64(output))
+	}
 	return &layer
 }
 
@@ -25,37 +29,96 @@ func LoadLinear(name string, params []*tensor.Tensor, args map[string]float32) L
 	layer.new("linear", name)
 	layer.output = int(args["output"])
 	layer.w = params[0]
+	if args["bias"] != 0 {
+		layer.b = params[1]
+	}
 	return &layer
 }
 
 func (layer *Linear) Forward(x *tensor.Tensor) *tensor.Tensor {
-	return x.MatMul(layer.w.Transpose(0, 1))
+	return linear(x, layer.w, layer.b)
+}
+
+func (layer *Linear) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
 }
 
 func (layer *Linear) Params() []*tensor.Tensor {
+	if layer.b != nil {
+		return []*tensor.Tensor{
+			layer.w,
+			layer.b,
+		}
+	}
 	return []*tensor.Tensor{
 		layer.w,
 	}
 }
 
+func (layer *Linear) SetParams(params []*tensor.Tensor) {
+	dst := []**tensor.Tensor{&layer.w}
+	if layer.b != nil {
+		dst = append(dst, &layer.b)
+	}
+	setParams(params, dst...)
+}
+
+func (layer *Linear) ParamNames() []string {
+	if layer.b != nil {
+		return []string{
+			"w",
+			"b",
+		}
+	}
+	return []string{
+		"w",
+	}
+}
+
 func (layer *Linear) Args() map[string]float32 {
+	var bias float32
+	if layer.b != nil {
+		bias = 1
+	}
 	return map[string]float32{
 		"output": float32(layer.output),
+		"bias":   bias,
 	}
 }
 
 func (layer *Linear) Freeze() {
 	layer.w.SetRequiresGrad(false)
+	if layer.b != nil {
+		layer.b.SetRequiresGrad(false)
+	}
 }
 
 func (layer *Linear) Unfreeze() {
 	layer.w.SetRequiresGrad(true)
+	if layer.b != nil {
+		layer.b.SetRequiresGrad(true)
+	}
 }
 
 func (layer *Linear) ToScalarType(t consts.ScalarType) {
 	layer.w = layer.w.ToScalarType(t)
+	if layer.b != nil {
+		layer.b = layer.b.ToScalarType(t)
+	}
 }
 
 func (layer *Linear) Reset() {
 	layer.w = layer.initW(layer.w.Shapes()...)
+	if layer.b != nil {
+		layer.b = layer.zeros(layer.b.Shapes()...)
+	}
+}
+
+// linear computes x*w^T+b, b can be nil
+func linear(x, w, b *tensor.Tensor) *tensor.Tensor {
+	y := x.MatMul(w.Transpose(0, 1))
+	if b != nil {
+		y = y.Add(b)
+	}
+	return y
 }
@@ -6,151 +6,127 @@ import (
 )
 
 type Lstm struct {
-	base
-	featureSize, steps int
-	hidden             int
-	Wi, Bi             *tensor.Tensor
-	Wf, Bf             *tensor.Tensor
-	Wg, Bg             *tensor.Tensor
-	Wo, Bo             *tensor.Tensor
+	recurrent
+	// params of the first layer in forward direction
+	Wi, Bi *tensor.Tensor
+	Wf, Bf *tensor.Tensor
+	Wg, Bg *tensor.Tensor
+	Wo, Bo *tensor.Tensor
 }
 
+func newLstmCell(r *recurrent, input int64) []*tensor.Tensor {
+	return []*tensor.Tensor{
+		r.initW(input+int64(r.hidden), int64(r.hidden)), // Wi
+		r.initW(input+int64(r.hidden), int64(r.hidden)), // Wf
+		r.initW(input+int64(r.hidden), int64(r.hidden)), // Wg
+		r.initW(input+int64(r.hidden), int64(r.hidden)), // Wo
+		r.initB(int64(r.hidden)),                        // Bi
+		r.initB(int64(r.hidden)),                        // Bf
+		r.initB(int64(r.hidden)),                        // Bg
+		r.initB(int64(r.hidden)),                        // Bo
+	}
+}
+
+func lstmCell(params []*tensor.Tensor, x *tensor.Tensor, states []*tensor.Tensor) []*tensor.Tensor {
+	h, c := states[0], states[1]
+	z := tensor.HStack(x, h)                // (batch, feature+hidden)
+	i := z.MatMul(params[0]).Add(params[4]) // (batch, hidden)
+	i = i.Sigmoid()                         // (batch, hidden)
+	f := z.MatMul(params[1]).Add(params[5]) // (batch, hidden)
+	f = f.Sigmoid()                         // (batch, hidden)
+	g := z.MatMul(params[2]).Add(params[6]) // (batch, hidden)
+	g = g.Tanh()                            // (batch, hidden)
+	o := z.MatMul(params[3]).Add(params[7]) // (batch, hidden)
+	o = o.Sigmoid()                         // (batch, hidden)
+	a := f.Mul(c)                           // (batch, hidden)
+	b := i.Mul(g)                           // (batch, hidden)
+	c = a.Add(b)                            // (batch, hidden)
+	h = o.Mul(c.Tanh())                     // (batch, hidden)
+	return []*tensor.Tensor{h, c}
+}
+
+var lstmParamNames = []string{"Wi", "Wf", "Wg", "Wo", "Bi", "Bf", "Bg", "Bo"}
+
+// NewLstm creates lstm layer, steps is only saved in Args,
+// Forward infers the count of steps from the input
 func NewLstm(name string, featureSize, steps, hidden int, opts ...LayerCreateOption) *Lstm {
 	var layer Lstm
 	layer.new("lstm", name, opts...)
-	layer.featureSize = featureSize
-	layer.steps = steps
-	layer.hidden = hidden
-	layer.Wi = layer.initW(int64(featureSize+hidden), int64(hidden))
-	layer.Wf = layer.initW(int64(featureSize+hidden), int64(hidden))
-	layer.Wg = layer.initW(int64(featureSize+hidden), int64(hidden))
-	layer.Wo = layer.initW(int64(featureSize+hidden), int64(hidden))
-	layer.Bi = layer.initB(int64(hidden))
-	layer.Bf = layer.initB(int64(hidden))
-	layer.Bg = layer.initB(int64(hidden))
-	layer.Bo = layer.initB(int64(hidden))
+	layer.setup(featureSize, steps, hidden, lstmParamNames, newLstmCell)
+	layer.bind()
 	return &layer
 }
 
 func LoadLstm(name string, params []*tensor.Tensor, args map[string]float32) Layer {
 	var layer Lstm
 	layer.new("lstm", name)
-	layer.featureSize = int(args["feature_size"])
-	layer.steps = int(args["steps"])
-	layer.hidden = int(args["hidden"])
-	layer.Wi = params[0]
-	layer.Wf = params[1]
-	layer.Wg = params[2]
-	layer.Wo = params[3]
-	layer.Bi = params[4]
-	layer.Bf = params[5]
-	layer.Bg = params[6]
-	layer.Bo = params[7]
+	layer.load(params, args, lstmParamNames, newLstmCell)
+	layer.bind()
 	return &layer
 }
 
+// bind points the exported params to the first cell
+func (layer *Lstm) bind() {
+	cell := layer.cells[0]
+	layer.Wi, layer.Wf, layer.Wg, layer.Wo = cell[0], cell[1], cell[2], cell[3]
+	layer.Bi, layer.Bf, layer.Bg, layer.Bo = cell[4], cell[5], cell[6], cell[7]
+}
+
+// Forward runs without the dropout between layers, use ForwardMask to train with dropout
 func (layer *Lstm) Forward(x, h, c *tensor.Tensor) (*tensor.Tensor, *tensor.Tensor, *tensor.Tensor) {
-	inputShape := x.Shapes()
-	if h == nil {
-		h = tensor.Zeros(consts.KFloat,
-			tensor.WithShapes(int64(inputShape[0]), int64(layer.hidden)),
-			tensor.WithDevice(layer.device))
-	}
-	if c == nil {
-		c = tensor.Zeros(consts.KFloat,
-			tensor.WithShapes(int64(inputShape[0]), int64(layer.hidden)),
-			tensor.WithDevice(layer.device))
-	}
-	x = x.Transpose(1, 0) // (steps, batch, feature)
-	var result *tensor.Tensor
-	for step := 0; step < layer.steps; step++ {
-		t := x.NArrow(0, int64(step), 1).
-			Reshape(int64(inputShape[0]), int64(layer.featureSize)) // (batch, feature)
-		z := tensor.HStack(t, h)              // (batch, feature+hidden)
-		i := z.MatMul(layer.Wi).Add(layer.Bi) // (batch, hidden)
-		i = i.Sigmoid()                       // (batch, hidden)
-		f := z.MatMul(layer.Wf).Add(layer.Bf) // (batch, hidden)
-		f = f.Sigmoid()                       // (batch, hidden)
-		o := z.MatMul(layer.Wo).Add(layer.Bo) // (batch, hidden)
-		o = o.Sigmoid()                       // (batch, hidden)
-		g := z.MatMul(layer.Wg).Add(layer.Bg) // (batch, hidden)
-		g = g.Tanh()                          // (batch, hidden)
-		a := f.Mul(c)                         // (batch, hidden)
-		b := i.Mul(g)                         // (batch, hidden)
-		c = a.Add(b)                          // (batch, hidden)
-		h = o.Mul(c.Tanh())                   // (batch, hidden)
-		if result == nil {
-			result = h
-		} else {
-			result = tensor.VStack(result, h)
-		}
-	}
-	return result.Reshape(inputShape[0], inputShape[1], int64(layer.hidden)),
-		copyState(h),
-		copyState(c)
+	return layer.ForwardMask(x, nil, h, c, false)
 }
 
-func (layer *Lstm) Params() []*tensor.Tensor {
-	return []*tensor.Tensor{
-		layer.Wi,
-		layer.Wf,
-		layer.Wg,
-		layer.Wo,
-		layer.Bi,
-		layer.Bf,
-		layer.Bg,
-		layer.Bo,
-	}
+// ForwardLengths runs the first lengths[i] steps of sample i, see ForwardMask
+func (layer *Lstm) ForwardLengths(x *tensor.Tensor, lengths []int64, h, c *tensor.Tensor, train bool) (*tensor.Tensor, *tensor.Tensor, *tensor.Tensor) {
+	return layer.ForwardMask(x, lengthMask(x, lengths), h, c, train)
 }
 
-func (layer *Lstm) Args() map[string]float32 {
-	return map[string]float32{
-		"feature_size": float32(layer.featureSize),
-		"steps":        float32(layer.steps),
-		"hidden":       float32(layer.hidden),
-	}
+// ForwardMask runs x (batch, steps, feature) with the count of steps inferred from x,
+// mask (batch, steps) is 1 on valid steps and 0 on padded steps, the hidden and cell state
+// are not updated on padded steps so the returned states are the ones of each sample's
+// last valid step, the output of padded steps is 0. mask can be nil.
+//
+// The hidden and cell state are (batch, hidden) for the single layer in one direction and
+// (num_layers*dirs, batch, hidden) otherwise
+func (layer *Lstm) ForwardMask(x, mask, h, c *tensor.Tensor, train bool) (*tensor.Tensor, *tensor.Tensor, *tensor.Tensor) {
+	y, states := layer.forward(x, mask, []*tensor.Tensor{h, c}, train, lstmCell)
+	return y, states[0], states[1]
 }
 
-func (layer *Lstm) Freeze() {
-	layer.Wi.SetRequiresGrad(false)
-	layer.Wf.SetRequiresGrad(false)
-	layer.Wg.SetRequiresGrad(false)
-	layer.Wo.SetRequiresGrad(false)
-	layer.Bi.SetRequiresGrad(false)
-	layer.Bf.SetRequiresGrad(false)
-	layer.Bg.SetRequiresGrad(false)
-	layer.Bo.SetRequiresGrad(false)
+func (layer *Lstm) Call(x *tensor.Tensor, train bool) *tensor.Tensor {
+	y, _, _ := layer.ForwardMask(x, nil, nil, nil, train)
+	return y
+}
+
+// CallMulti inputs are x, optional hidden and cell state and an optional mask (batch, steps),
+// outputs are y, hidden and cell state
+func (layer *Lstm) CallMulti(train bool, xs ...*tensor.Tensor) []*tensor.Tensor {
+	var h, c, mask *tensor.Tensor
+	if len(xs) > 1 {
+		h = xs[1]
+	}
+	if len(xs) > 2 {
+		c = xs[2]
+	}
+	if len(xs) > 3 {
+		mask = xs[3]
+	}
+	y, h, c := layer.ForwardMask(xs[0], mask, h, c, train)
+	return []*tensor.Tensor{y, h, c}
 }
 
-func (layer *Lstm) Unfreeze() {
-	layer.Wi.SetRequiresGrad(true)
-	layer.Wf.SetRequiresGrad(true)
-	layer.Wg.SetRequiresGrad(true)
-	layer.Wo.SetRequiresGrad(true)
-	layer.Bi.SetRequiresGrad(true)
-	layer.Bf.SetRequiresGrad(true)
-	layer.Bg.SetRequiresGrad(true)
-	layer.Bo.SetRequiresGrad(true)
+func (layer *Lstm) SetParams(params []*tensor.Tensor) {
+	layer.recurrent.SetParams(params)
+	layer.bind()
 }
 
 func (layer *Lstm) ToScalarType(t consts.ScalarType) {
-	layer.Wi = layer.Wi.ToScalarType(t)
-	layer.Wf = layer.Wf.ToScalarType(t)
-	layer.Wg = layer.Wg.ToScalarType(t)
-	layer.Wo = layer.Wo.ToScalarType(t)
-	layer.Bi = layer.Bi.ToScalarType(t)
-	layer.Bf = layer.Bf.ToScalarType(t)
-	layer.Bg = layer.Bg.ToScalarType(t)
-	layer.Bo = layer.Bo.ToScalarType(t)
+	layer.recurrent.ToScalarType(t)
+	layer.bind()
 }
 
 func (layer *Lstm) Reset() {
-	layer.Wi = layer.initW(layer.Wi.Shapes()...)
-	layer.Wf = layer.initW(layer.Wf.Shapes()...)
-	layer.Wg = layer.initW(layer.Wg.Shapes()...)
-	layer.Wo = layer.initW(layer.Wo.Shapes()...)
-	layer.Bi = layer.initB(layer.Bi.Shapes()...)
-	layer.Bf = layer.initB(layer.Bf.Shapes()...)
-	layer.Bg = layer.initB(layer.Bg.Shapes()...)
-	layer.Bo = layer.initB(layer.Bo.Shapes()...)
+	layer.recurrent.Reset()
+	layer.bind()
 }
@@ -0,0 +1,67 @@
+package layer
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+// the masks are added to the attention score, the masked positions are -inf and others are 0
+
+// CausalMask builds the (1, 1, l, s) mask of l queries attending to s keys, the query i
+// is at position offset+i and can only attend to the keys at or before it
+func CausalMask(l, s, offset int64, device consts.DeviceType, t consts.ScalarType) *tensor.Tensor {
+	mask := make([]float32, l*s)
+	for i := int64(0); i < l; i++ {
+		for j := int64(0); j < s; j++ {
+			if j > i+offset {
+				mask[i*s+j] = float32(math.Inf(-1))
+			}
+		}
+	}
+	return tensor.FromFloat32(mask,
+		tensor.WithShapes(1, 1, l, s),
+		tensor.WithDevice(device)).ToScalarType(t)
+}
+
+// PaddingMask builds the (batch, 1, 1, seq) mask of the keys, the first lengths[i] keys of
+// sample i are valid and others are masked, lengths must be positive
+func PaddingMask(lengths []int64, seq int64, device consts.DeviceType, t consts.ScalarType) *tensor.Tensor {
+	mask := make([]float32, int64(len(lengths))*seq)
+	for i, n := range lengths {
+		if n <= 0 || n > seq {
+			panic(fmt.Errorf("invalid length %d of sample %d, seq is %d", n, i, seq))
+		}
+		for j := n; j < seq; j++ {
+			mask[int64(i)*seq+j] = float32(math.Inf(-1))
+		}
+	}
+	return tensor.FromFloat32(mask,
+		tensor.WithShapes(int64(len(lengths)), 1, 1, seq),
+		tensor.WithDevice(device)).ToScalarType(t)
+}
+
+// KeyPaddingMask converts mask (batch, seq) which is 1 on valid keys and 0 on padded keys,
+// e.g. the mask of Rnn.ForwardMask, to the (batch, 1, 1, seq) mask of t on the device of mask,
+// the padded keys are -1e9 instead of -inf
+func KeyPaddingMask(mask *tensor.Tensor, t consts.ScalarType) *tensor.Tensor {
+	shapes := mask.Shapes()
+	scalar := func(n float32) *tensor.Tensor {
+		return tensor.FromFloat32([]float32{n},
+			tensor.WithShapes(1),
+			tensor.WithDevice(mask.DeviceType()))
+	}
+	y := mask.ToScalarType(consts.KFloat).Sub(scalar(1)).Mul(scalar(1e9))
+	return y.Reshape(shapes[0], 1, 1, shapes[1]).ToScalarType(t)
+}
+
+// CombineMasks returns the sum of masks which broadcast to each other, the nil masks are skipped
+func CombineMasks(masks ...*tensor.Tensor) *tensor.Tensor {
+	var ret *tensor.Tensor
+	for _, mask := range masks {
+		ret = addMask(ret, mask)
+	}
+	return ret
+}
@@ -0,0 +1,44 @@
+package layer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+func TestMasks(t *testing.T) {
+	inf := float32(math.Inf(-1))
+	causal := CausalMask(2, 3, 1, consts.KCPU, consts.KFloat).Float32Value()
+	assertClose(t, "causal", causal, []float32{0, 0, inf, 0, 0, 0})
+
+	padding := PaddingMask([]int64{1, 3}, 3, consts.KCPU, consts.KFloat).Float32Value()
+	assertClose(t, "padding", padding, []float32{0, inf, inf, 0, 0, 0})
+
+	valid := tensor.FromFloat32([]float32{1, 1, 0}, tensor.WithShapes(1, 3))
+	key := KeyPaddingMask(valid, consts.KFloat)
+	if shapes := key.Shapes(); len(shapes) != 4 || shapes[3] != 3 {
+		t.Fatalf("unexpected key padding shapes: %v", shapes)
+	}
+	assertClose(t, "key padding", key.Float32Value(), []float32{0, 0, -1e9})
+
+	combined := CombineMasks(nil, PaddingMask([]int64{2}, 2, consts.KCPU, consts.KFloat),
+		CausalMask(2, 2, 0, consts.KCPU, consts.KFloat))
+	assertClose(t, "combined", combined.Float32Value(), []float32{0, inf, 0, inf})
+}
+
+func TestAttentionCausalPadding(t *testing.T) {
+	x := tensor.ARange(2*3*4, consts.KFloat).Reshape(2, 3, 4)
+	mask := PaddingMask([]int64{3, 3}, 3, consts.KCPU, consts.KFloat)
+	for _, l := range []interface {
+		Forward(q, k, v, mask *tensor.Tensor, isCausal, train bool) *tensor.Tensor
+	}{
+		NewAttention("attn", 4, 2, 0, false),
+		NewAttention1("attn1", 4, 2, 0, false),
+	} {
+		// nothing is padded so the result equals the causal attention
+		assertClose(t, "causal padding", l.Forward(x, x, x, mask, true, false).Float32Value(),
+			l.Forward(x, x, x, nil, true, false).Float32Value())
+	}
+}
@@ -1,84 +1,30 @@
 package layer
 
 import (
-	"github.com/lwch/gotorch/consts"
 	"github.com/lwch/gotorch/tensor"
 )
 
 type MaxPool1D struct {
-	base
-	kernel   int
-	stride   int
-	padding  int
-	dilation int
-	ceil     bool
+	maxPooling
 }
 
 func NewMaxPool1D(name string, kernel int, opts ...LayerCreateOption) *MaxPool1D {
 	var layer MaxPool1D
-	layer.new("maxpool1d", name, opts...)
-	layer.kernel = kernel
-	layer.stride = -1
-	layer.padding = 0
-	layer.dilation = 1
-	layer.ceil = false
+	layer.init("maxpool1d", name, kernel, opts...)
 	return &layer
 }
 
-func (layer *MaxPool1D) SetStride(stride int) {
-	layer.stride = stride
-}
-
-func (layer *MaxPool1D) SetPadding(padding int) {
-	layer.padding = padding
-}
-
-func (layer *MaxPool1D) SetDilation(dilation int) {
-	layer.dilation = dilation
-}
-
-func (layer *MaxPool1D) SetCeil(ceil bool) {
-	layer.ceil = ceil
-}
-
 func LoadMaxPool1D(name string, _ []*tensor.Tensor, args map[string]float32) Layer {
 	var layer MaxPool1D
-	layer.new("maxpool1d", name)
-	layer.kernel = int(args["kernel"])
-	layer.stride = int(args["stride"])
-	layer.padding = int(args["padding"])
-	layer.dilation = int(args["dilation"])
-	layer.ceil = args["ceil"] > 0
+	layer.load("maxpool1d", name, args)
 	return &layer
 }
 
+// Forward pools x (batch, channels, length) or (channels, length)
 func (layer *MaxPool1D) Forward(x *tensor.Tensor) *tensor.Tensor {
-	if layer.stride < 0 {
-		layer.stride = layer.kernel
-	}
-	return x.MaxPool1D(layer.kernel,
-		tensor.PoolStride(layer.stride),
-		tensor.PoolPadding(layer.padding),
-		tensor.PoolDilation(layer.dilation),
-		tensor.PoolCeil(layer.ceil))
-}
-
-func (layer *MaxPool1D) Args() map[string]float32 {
-	var ceil float32
-	if layer.ceil {
-		ceil = 1
-	}
-	return map[string]float32{
-		"kernel":   float32(layer.kernel),
-		"stride":   float32(layer.stride),
-		"padding":  float32(layer.padding),
-		"dilation": float32(layer.dilation),
-		"ceil":     ceil,
-	}
-}
-
-func (layer *MaxPool1D) ToScalarType(t consts.ScalarType) {
+	return x.MaxPool1D(layer.kernel, layer.opts()...)
 }
 
-func (layer *MaxPool1D) Reset() {
+func (layer *MaxPool1D) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
 }
@@ -0,0 +1,30 @@
+package layer
+
+import (
+	"github.com/lwch/gotorch/tensor"
+)
+
+type MaxPool2D struct {
+	maxPooling
+}
+
+func NewMaxPool2D(name string, kernel int, opts ...LayerCreateOption) *MaxPool2D {
+	var layer MaxPool2D
+	layer.init("maxpool2d", name, kernel, opts...)
+	return &layer
+}
+
+func LoadMaxPool2D(name string, _ []*tensor.Tensor, args map[string]float32) Layer {
+	var layer MaxPool2D
+	layer.load("maxpool2d", name, args)
+	return &layer
+}
+
+// Forward pools x (batch, channels, height, width) or (channels, height, width)
+func (layer *MaxPool2D) Forward(x *tensor.Tensor) *tensor.Tensor {
+	return x.MaxPool2D(layer.kernel, layer.opts()...)
+}
+
+func (layer *MaxPool2D) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
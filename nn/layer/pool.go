@@ -0,0 +1,155 @@
+package layer
+
+import (
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+// pooling is the common part of the max and average pooling layers,
+// the kernel, stride and padding are the same on each spatial dim
+type pooling struct {
+	base
+	kernel  int
+	stride  int
+	padding int
+	ceil    bool
+}
+
+func (p *pooling) init(class, name string, kernel int, opts ...LayerCreateOption) {
+	p.new(class, name, opts...)
+	p.kernel = kernel
+	p.stride = -1
+}
+
+func (p *pooling) load(class, name string, args map[string]float32) {
+	p.new(class, name)
+	p.kernel = int(args["kernel"])
+	p.stride = int(args["stride"])
+	p.padding = int(args["padding"])
+	p.ceil = args["ceil"] > 0
+}
+
+// SetStride sets the stride of the window, default is the kernel size
+func (p *pooling) SetStride(stride int) {
+	p.stride = stride
+}
+
+// SetPadding pads padding elements on both sides of each spatial dim
+func (p *pooling) SetPadding(padding int) {
+	p.padding = padding
+}
+
+// SetCeil uses ceil instead of floor to compute the output shape
+func (p *pooling) SetCeil(ceil bool) {
+	p.ceil = ceil
+}
+
+func (p *pooling) getStride() int {
+	if p.stride < 0 {
+		return p.kernel
+	}
+	return p.stride
+}
+
+func (p *pooling) args() map[string]float32 {
+	var ceil float32
+	if p.ceil {
+		ceil = 1
+	}
+	return map[string]float32{
+		"kernel":  float32(p.kernel),
+		"stride":  float32(p.stride),
+		"padding": float32(p.padding),
+		"ceil":    ceil,
+	}
+}
+
+func (p *pooling) ToScalarType(t consts.ScalarType) {
+}
+
+func (p *pooling) Reset() {
+}
+
+// maxPooling is the common part of MaxPool1D and MaxPool2D
+type maxPooling struct {
+	pooling
+	dilation int
+}
+
+func (p *maxPooling) init(class, name string, kernel int, opts ...LayerCreateOption) {
+	p.pooling.init(class, name, kernel, opts...)
+	p.dilation = 1
+}
+
+func (p *maxPooling) load(class, name string, args map[string]float32) {
+	p.pooling.load(class, name, args)
+	p.dilation = int(args["dilation"])
+	if p.dilation <= 0 {
+		p.dilation = 1
+	}
+}
+
+func (p *maxPooling) SetDilation(dilation int) {
+	p.dilation = dilation
+}
+
+func (p *maxPooling) opts() []tensor.PoolOpt {
+	return []tensor.PoolOpt{
+		tensor.PoolStride(p.getStride()),
+		tensor.PoolPadding(p.padding),
+		tensor.PoolDilation(p.dilation),
+		tensor.PoolCeil(p.ceil),
+	}
+}
+
+func (p *maxPooling) Args() map[string]float32 {
+	ret := p.args()
+	ret["dilation"] = float32(p.dilation)
+	return ret
+}
+
+// avgPooling is the common part of AvgPool1D and AvgPool2D
+type avgPooling struct {
+	pooling
+	countIncludePad bool
+}
+
+func (p *avgPooling) init(class, name string, kernel int, opts ...LayerCreateOption) {
+	p.pooling.init(class, name, kernel, opts...)
+	p.countIncludePad = true
+}
+
+func (p *avgPooling) load(class, name string, args map[string]float32) {
+	p.pooling.load(class, name, args)
+	p.countIncludePad = args["count_include_pad"] != 0
+}
+
+// SetCountIncludePad counts the padded zeros in the average, default is true
+func (p *avgPooling) SetCountIncludePad(include bool) {
+	p.countIncludePad = include
+}
+
+// opts returns the options of tensor.AvgPool1D and tensor.AvgPool2D, gotorch passes
+// the dilation as ceil_mode and ceil as count_include_pad to torch::avg_pool
+func (p *avgPooling) opts() []tensor.PoolOpt {
+	var ceil int
+	if p.ceil {
+		ceil = 1
+	}
+	return []tensor.PoolOpt{
+		tensor.PoolStride(p.getStride()),
+		tensor.PoolPadding(p.padding),
+		tensor.PoolDilation(ceil),
+		tensor.PoolCeil(p.countIncludePad),
+	}
+}
+
+func (p *avgPooling) Args() map[string]float32 {
+	ret := p.args()
+	var include float32
+	if p.countIncludePad {
+		include = 1
+	}
+	ret["count_include_pad"] = include
+	return ret
+}
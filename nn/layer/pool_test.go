@@ -0,0 +1,55 @@
+package layer
+
+import (
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+func assertShapes(t *testing.T, name string, x *tensor.Tensor, shapes ...int64) {
+	got := x.Shapes()
+	if len(got) != len(shapes) {
+		t.Fatalf("%s: unexpected shapes %v, expected %v", name, got, shapes)
+	}
+	for i := range got {
+		if got[i] != shapes[i] {
+			t.Fatalf("%s: unexpected shapes %v, expected %v", name, got, shapes)
+		}
+	}
+}
+
+func TestPooling(t *testing.T) {
+	x := tensor.ARange(5*5, consts.KFloat).Reshape(1, 1, 5, 5)
+	pool := NewMaxPool2D("pool", 2)
+	assertShapes(t, "maxpool2d", pool.Forward(x), 1, 1, 2, 2)
+	pool.SetCeil(true)
+	assertShapes(t, "maxpool2d ceil", pool.Forward(x), 1, 1, 3, 3)
+	loaded := LoadMaxPool2D("pool", nil, pool.Args()).(*MaxPool2D)
+	assertShapes(t, "maxpool2d load", loaded.Forward(x), 1, 1, 3, 3)
+
+	seq := tensor.FromFloat32([]float32{1, 2, 3, 4}, tensor.WithShapes(1, 1, 4))
+	avg := NewAvgPool1D("avg", 2)
+	assertClose(t, "avgpool1d", avg.Forward(seq).Float32Value(), []float32{1.5, 3.5})
+	avg.SetPadding(1)
+	assertClose(t, "avgpool1d padding", avg.Forward(seq).Float32Value(), []float32{0.5, 2.5, 2})
+	avg.SetCountIncludePad(false)
+	assertClose(t, "avgpool1d exclude padding", avg.Forward(seq).Float32Value(), []float32{1, 2.5, 4})
+	assertShapes(t, "avgpool2d", NewAvgPool2D("avg", 3).Forward(x), 1, 1, 1, 1)
+}
+
+func TestAdaptivePooling(t *testing.T) {
+	seq := tensor.FromFloat32([]float32{1, 2, 3, 4, 5}, tensor.WithShapes(1, 1, 5))
+	// windows are [0, 2), [1, 4), [3, 5)
+	assertClose(t, "adaptive avg", NewAdaptiveAvgPool1D("avg", 3).Forward(seq).Float32Value(), []float32{1.5, 3, 4.5})
+	assertClose(t, "adaptive max", NewAdaptiveMaxPool1D("max", 3).Forward(seq).Float32Value(), []float32{2, 4, 5})
+
+	x := tensor.ARange(2*3*7*5, consts.KFloat).Reshape(2, 3, 7, 5)
+	pool := NewAdaptiveAvgPool2D("pool", 3, 2)
+	assertShapes(t, "adaptive avg 2d", pool.Forward(x), 2, 3, 3, 2)
+	loaded := LoadAdaptiveMaxPool2D("pool", nil, NewAdaptiveMaxPool2D("pool", 3, 2).Args())
+	assertShapes(t, "adaptive max 2d", loaded.(*AdaptiveMaxPool2D).Forward(x), 2, 3, 3, 2)
+
+	assertShapes(t, "global avg", NewGlobalAvgPool("avg").Forward(x), 2, 3)
+	assertClose(t, "global max", NewGlobalMaxPool("max").Forward(seq).Float32Value(), []float32{5})
+}
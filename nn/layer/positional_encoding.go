@@ -0,0 +1,180 @@
+package layer
+
+import (
+	"math"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+// SinusoidalEncoding adds the fixed sin/cos positional encoding to the input,
+// the encoding of position p is sin(p/base^(2i/dims)) at 2i and cos(p/base^(2i/dims)) at 2i+1
+type SinusoidalEncoding struct {
+	base
+	dims    int
+	encBase float64
+	// runtime
+	table *tensor.Tensor
+}
+
+func NewSinusoidalEncoding(name string, dims int, opts ...LayerCreateOption) *SinusoidalEncoding {
+	var layer SinusoidalEncoding
+	layer.new("sinusoidal_encoding", name, opts...)
+	layer.dims = dims
+	layer.encBase = 10000
+	return &layer
+}
+
+func LoadSinusoidalEncoding(name string, _ []*tensor.Tensor, args map[string]float32) Layer {
+	var layer SinusoidalEncoding
+	layer.new("sinusoidal_encoding", name)
+	layer.dims = int(args["dims"])
+	layer.encBase = float64(args["base"])
+	return &layer
+}
+
+// SetBase sets the base of the wave length, default is 10000
+func (layer *SinusoidalEncoding) SetBase(n float64) {
+	layer.encBase = n
+	layer.table = nil
+}
+
+// build builds the encoding of the first seq positions, it is extended when a longer sequence is given
+func (layer *SinusoidalEncoding) build(seq int64, device consts.DeviceType) {
+	if layer.table != nil && layer.table.Shapes()[1] >= seq && layer.table.DeviceType() == device {
+		return
+	}
+	data := make([]float32, seq*int64(layer.dims))
+	for k := int64(0); k < seq; k++ {
+		start := k * int64(layer.dims)
+		for i := 0; i < layer.dims/2; i++ {
+			n := float64(k) / math.Pow(layer.encBase, 2*float64(i)/float64(layer.dims))
+			data[start+int64(i*2)] = float32(math.Sin(n))
+			data[start+int64(i*2+1)] = float32(math.Cos(n))
+		}
+	}
+	layer.table = tensor.FromFloat32(data,
+		tensor.WithShapes(1, seq, int64(layer.dims)),
+		tensor.WithDevice(device))
+}
+
+// Forward adds the encoding to x (batch, seq, dims)
+func (layer *SinusoidalEncoding) Forward(x *tensor.Tensor) *tensor.Tensor {
+	return layer.ForwardOffset(x, 0)
+}
+
+// ForwardOffset adds the encoding of the positions begin at offset to x (batch, seq, dims),
+// e.g. the tokens following the ones in KVCache
+func (layer *SinusoidalEncoding) ForwardOffset(x *tensor.Tensor, offset int64) *tensor.Tensor {
+	seq := x.Shapes()[1]
+	layer.build(offset+seq, x.DeviceType())
+	return x.Add(layer.table.NArrow(1, offset, seq).ToScalarType(x.ScalarType()))
+}
+
+func (layer *SinusoidalEncoding) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
+func (layer *SinusoidalEncoding) Args() map[string]float32 {
+	return map[string]float32{
+		"dims": float32(layer.dims),
+		"base": float32(layer.encBase),
+	}
+}
+
+func (layer *SinusoidalEncoding) Freeze() {
+}
+
+func (layer *SinusoidalEncoding) Unfreeze() {
+}
+
+func (layer *SinusoidalEncoding) ToScalarType(t consts.ScalarType) {
+}
+
+func (layer *SinusoidalEncoding) Reset() {
+}
+
+// PositionEmbedding adds a learned embedding of each position to the input,
+// the input can not be longer than maxLen
+type PositionEmbedding struct {
+	base
+	maxLen int
+	dims   int
+	// params
+	w *tensor.Tensor
+}
+
+func NewPositionEmbedding(name string, maxLen, dims int, opts ...LayerCreateOption) *PositionEmbedding {
+	var layer PositionEmbedding
+	layer.new("position_embedding", name, opts...)
+	layer.maxLen = maxLen
+	layer.dims = dims
+	layer.w = layer.initW(int64(maxLen), int64(dims))
+	return &layer
+}
+
+func LoadPositionEmbedding(name string, params []*tensor.Tensor, args map[string]float32) Layer {
+	var layer PositionEmbedding
+	layer.new("position_embedding", name)
+	layer.maxLen = int(args["max_len"])
+	layer.dims = int(args["dims"])
+	layer.w = params[0]
+	return &layer
+}
+
+// Forward adds the embedding to x (batch, seq, dims)
+func (layer *PositionEmbedding) Forward(x *tensor.Tensor) *tensor.Tensor {
+	return layer.ForwardOffset(x, 0)
+}
+
+// ForwardOffset adds the embedding of the positions begin at offset to x (batch, seq, dims)
+func (layer *PositionEmbedding) ForwardOffset(x *tensor.Tensor, offset int64) *tensor.Tensor {
+	seq := x.Shapes()[1]
+	if offset+seq > int64(layer.maxLen) {
+		panic("sequence is longer than max length")
+	}
+	return x.Add(layer.w.NArrow(0, offset, seq))
+}
+
+func (layer *PositionEmbedding) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
+func (layer *PositionEmbedding) Params() []*tensor.Tensor {
+	return []*tensor.Tensor{
+		layer.w,
+	}
+}
+
+func (layer *PositionEmbedding) SetParams(params []*tensor.Tensor) {
+	setParams(params, &layer.w)
+}
+
+func (layer *PositionEmbedding) ParamNames() []string {
+	return []string{
+		"w",
+	}
+}
+
+func (layer *PositionEmbedding) Args() map[string]float32 {
+	return map[string]float32{
+		"max_len": float32(layer.maxLen),
+		"dims":    float32(layer.dims),
+	}
+}
+
+func (layer *PositionEmbedding) Freeze() {
+	layer.w.SetRequiresGrad(false)
+}
+
+func (layer *PositionEmbedding) Unfreeze() {
+	layer.w.SetRequiresGrad(true)
+}
+
+func (layer *PositionEmbedding) ToScalarType(t consts.ScalarType) {
+	layer.w = layer.w.ToScalarType(t)
+}
+
+func (layer *PositionEmbedding) Reset() {
+	layer.w = layer.initW(layer.w.Shapes()...)
+}
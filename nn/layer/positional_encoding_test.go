@@ -0,0 +1,35 @@
+package layer
+
+import (
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+func TestSinusoidalEncoding(t *testing.T) {
+	l := NewSinusoidalEncoding("pe", 4)
+	x := tensor.Zeros(consts.KFloat, tensor.WithShapes(1, 3, 4))
+	y := l.Forward(x).Float32Value()
+	// sin(0), cos(0) of position 0
+	if y[0] != 0 || y[1] != 1 {
+		t.Fatalf("unexpected encoding: %v", y[:4])
+	}
+	// the table is extended for the longer sequence
+	long := l.Forward(tensor.Zeros(consts.KFloat, tensor.WithShapes(1, 6, 4)))
+	tail := l.ForwardOffset(tensor.Zeros(consts.KFloat, tensor.WithShapes(1, 2, 4)), 4)
+	assertClose(t, "offset", tail.Float32Value(), long.NArrow(1, 4, 2).Contiguous().Float32Value())
+}
+
+func TestPositionEmbedding(t *testing.T) {
+	l := NewPositionEmbedding("pe", 4, 2)
+	x := tensor.Zeros(consts.KFloat, tensor.WithShapes(1, 3, 2))
+	loaded := LoadPositionEmbedding("pe", l.Params(), l.Args()).(*PositionEmbedding)
+	assertClose(t, "position embedding", loaded.Forward(x).Float32Value(), l.Forward(x).Float32Value())
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expect panic for the sequence longer than max length")
+		}
+	}()
+	l.ForwardOffset(x, 2)
+}
@@ -0,0 +1,103 @@
+package layer
+
+import (
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+// PReLU is the relu with a learnable negative slope, it has one slope for all channels
+// when num is 1 or one slope for each channel on dim 1
+type PReLU struct {
+	base
+	num  int
+	init float64
+	// params
+	a *tensor.Tensor
+}
+
+func NewPReLU(name string, num int, opts ...LayerCreateOption) *PReLU {
+	var layer PReLU
+	layer.new("prelu", name, opts...)
+	layer.num = num
+	layer.init = 0.25
+	layer.a = layer.initA()
+	return &layer
+}
+
+// SetInit reinitializes the slope with v, default is 0.25
+func (layer *PReLU) SetInit(v float64) {
+	layer.init = v
+	layer.a = layer.initA()
+}
+
+func LoadPReLU(name string, params []*tensor.Tensor, args map[string]float32) Layer {
+	var layer PReLU
+	layer.new("prelu", name)
+	layer.num = int(args["num"])
+	layer.init = float64(args["init"])
+	layer.a = params[0]
+	return &layer
+}
+
+func (layer *PReLU) initA() *tensor.Tensor {
+	a := layer.initN(layer.init).Expand(int64(layer.num)).Contiguous()
+	a.SetRequiresGrad(true)
+	return a
+}
+
+// Forward computes max(x, 0) + a * min(x, 0)
+func (layer *PReLU) Forward(x *tensor.Tensor) *tensor.Tensor {
+	a := layer.a
+	if layer.num > 1 && x.Dims() > 2 {
+		shapes := make([]int64, x.Dims()-1)
+		shapes[0] = int64(layer.num)
+		for i := 1; i < len(shapes); i++ {
+			shapes[i] = 1
+		}
+		a = a.Reshape(shapes...)
+	}
+	return x.Relu().Sub(x.Neg().Relu().Mul(a))
+}
+
+func (layer *PReLU) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
+func (layer *PReLU) Params() []*tensor.Tensor {
+	return []*tensor.Tensor{
+		layer.a,
+	}
+}
+
+func (layer *PReLU) SetParams(params []*tensor.Tensor) {
+	setParams(params, &layer.a)
+}
+
+func (layer *PReLU) ParamNames() []string {
+	return []string{
+		"a",
+	}
+}
+
+func (layer *PReLU) Args() map[string]float32 {
+	return map[string]float32{
+		"num":  float32(layer.num),
+		"init": float32(layer.init),
+	}
+}
+
+func (layer *PReLU) Freeze() {
+	layer.a.SetRequiresGrad(false)
+}
+
+func (layer *PReLU) Unfreeze() {
+	layer.a.SetRequiresGrad(true)
+}
+
+func (layer *PReLU) ToScalarType(t consts.ScalarType) {
+	layer.a = layer.a.ToScalarType(t)
+}
+
+func (layer *PReLU) Reset() {
+	layer.a = layer.initA()
+}
@@ -0,0 +1,28 @@
+package layer
+
+import (
+	"testing"
+
+	"github.com/lwch/gotorch/tensor"
+)
+
+func TestPReLU(t *testing.T) {
+	x := tensor.FromFloat32([]float32{-4, 2, -4, 2}, tensor.WithShapes(1, 2, 2))
+	l := NewPReLU("prelu", 1)
+	assertClose(t, "shared slope", l.Forward(x).Float32Value(), []float32{-1, 2, -1, 2})
+
+	l = NewPReLU("prelu", 2)
+	l.SetInit(0.5)
+	assertShapes(t, "slope", l.Params()[0], 2)
+	assertClose(t, "channel slope", l.Forward(x).Float32Value(), []float32{-2, 2, -2, 2})
+	loaded := LoadPReLU("prelu", l.Params(), l.Args()).(*PReLU)
+	assertClose(t, "channel slope", loaded.Forward(x).Float32Value(), []float32{-2, 2, -2, 2})
+
+	// each channel on dim 1 uses its own slope
+	l.SetParams([]*tensor.Tensor{tensor.FromFloat32([]float32{0.5, 0.25}, tensor.WithShapes(2))})
+	assertClose(t, "distinct slopes", l.Forward(x).Float32Value(), []float32{-2, 2, -1, 2})
+	x2d := tensor.FromFloat32([]float32{-4, -4, 2, -8}, tensor.WithShapes(2, 2))
+	assertClose(t, "distinct slopes 2d", l.Forward(x2d).Float32Value(), []float32{-2, -1, 2, -2})
+	loaded = LoadPReLU("prelu", l.Params(), l.Args()).(*PReLU)
+	assertClose(t, "distinct slopes", loaded.Forward(x).Float32Value(), []float32{-2, 2, -1, 2})
+}
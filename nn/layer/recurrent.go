@@ -0,0 +1,359 @@
+package layer
+
+import (
+	"fmt"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+// cellFunc computes the next states of one step, the first state is the output
+type cellFunc func(params []*tensor.Tensor, x *tensor.Tensor, states []*tensor.Tensor) []*tensor.Tensor
+
+// recurrent is shared by Rnn, Lstm and Gru, it stacks numLayers layers in one or two directions,
+// cells[layer*dirs+dir] are the params of each layer and direction, cells[0] is the first
+// layer in forward direction which is the only one of the single layer models
+type recurrent struct {
+	base
+	featureSize, steps int
+	hidden             int
+	numLayers          int
+	bidirectional      bool
+	dropout            float64
+	detachState        bool
+	cells              [][]*tensor.Tensor
+	cellNames          []string
+	newCell            func(r *recurrent, input int64) []*tensor.Tensor
+}
+
+func (r *recurrent) setup(featureSize, steps, hidden int, names []string,
+	newCell func(r *recurrent, input int64) []*tensor.Tensor) {
+	r.featureSize = featureSize
+	r.steps = steps
+	r.hidden = hidden
+	r.numLayers = 1
+	r.detachState = true
+	r.cellNames = names
+	r.newCell = newCell
+	r.cells = [][]*tensor.Tensor{newCell(r, int64(featureSize))}
+}
+
+func (r *recurrent) load(params []*tensor.Tensor, args map[string]float32, names []string,
+	newCell func(r *recurrent, input int64) []*tensor.Tensor) {
+	r.featureSize = int(args["feature_size"])
+	r.steps = int(args["steps"])
+	r.hidden = int(args["hidden"])
+	r.numLayers = 1
+	if n := int(args["num_layers"]); n > 1 {
+		r.numLayers = n
+	}
+	r.bidirectional = args["bidirectional"] != 0
+	r.dropout = float64(args["dropout"])
+	r.detachState = true
+	if v, ok := args["detach_state"]; ok {
+		r.detachState = v != 0
+	}
+	r.cellNames = names
+	r.newCell = newCell
+	count := r.numLayers * r.dirs()
+	if len(params) != count*len(names) {
+		panic(fmt.Errorf("expected %d params, got %d", count*len(names), len(params)))
+	}
+	r.paramType = params[0].ScalarType()
+	r.cells = make([][]*tensor.Tensor, count)
+	for i := range r.cells {
+		r.cells[i] = params[i*len(names) : (i+1)*len(names)]
+	}
+}
+
+func (r *recurrent) dirs() int {
+	if r.bidirectional {
+		return 2
+	}
+	return 1
+}
+
+// rebuild creates the params of every layer and direction except the first one
+func (r *recurrent) rebuild() {
+	dirs := r.dirs()
+	cells := make([][]*tensor.Tensor, r.numLayers*dirs)
+	cells[0] = r.cells[0]
+	for i := 1; i < len(cells); i++ {
+		input := int64(r.featureSize)
+		if i >= dirs {
+			input = int64(r.hidden * dirs)
+		}
+		cells[i] = r.newCell(r, input)
+	}
+	r.cells = cells
+}
+
+// SetNumLayers stacks n layers, the output of each layer is the input of the next layer,
+// the params of the added layers are newly initialized
+func (r *recurrent) SetNumLayers(n int) {
+	if n < 1 {
+		panic(fmt.Errorf("invalid num_layers: %d", n))
+	}
+	r.numLayers = n
+	r.rebuild()
+}
+
+// SetBidirectional runs each layer in both directions and concats their outputs,
+// the output size is 2*hidden when enabled
+func (r *recurrent) SetBidirectional(b bool) {
+	r.bidirectional = b
+	r.rebuild()
+}
+
+// SetDropout drops the output of each layer except the last one with probability p in train mode
+func (r *recurrent) SetDropout(p float64) {
+	r.dropout = p
+}
+
+// SetDetachState detaches the returned states from the autograd graph when b is true (default),
+// so they can be fed into the next batch for truncated BPTT, otherwise the states stay on
+// the graph and the gradient flows back into the previous calls
+func (r *recurrent) SetDetachState(b bool) {
+	r.detachState = b
+}
+
+func (r *recurrent) scalarType() consts.ScalarType {
+	return r.cells[0][0].ScalarType()
+}
+
+// deviceType returns the device of the params, the device given to the layer is
+// always cpu after loading
+func (r *recurrent) deviceType() consts.DeviceType {
+	return r.cells[0][0].DeviceType()
+}
+
+// detach copies s into a new tensor without grad on the same device with the same scalar type.
+// gotorch has no detach api and no in-place copy, so the data goes through host memory,
+// forward calls it once for each returned state, use SetDetachState(false) to avoid the copy.
+func detach(s *tensor.Tensor) *tensor.Tensor {
+	opts := []tensor.Option{
+		tensor.WithShapes(s.Shapes()...),
+		tensor.WithDevice(s.DeviceType()),
+	}
+	cpu := s.ToDevice(consts.KCPU)
+	switch s.ScalarType() {
+	case consts.KBFloat16:
+		return tensor.FromBFloat16Raw(cpu.BFloat16Raw(), opts...)
+	case consts.KHalf:
+		return tensor.FromHalfRaw(cpu.HalfRaw(), opts...)
+	case consts.KDouble:
+		return tensor.FromFloat64(cpu.Float64Value(), opts...)
+	default:
+		return tensor.FromFloat32(cpu.Float32Value(), opts...)
+	}
+}
+
+// lengthMask builds the (batch, steps) mask of x (batch, steps, ...),
+// the first lengths[i] steps of sample i are 1 and others are 0
+func lengthMask(x *tensor.Tensor, lengths []int64) *tensor.Tensor {
+	shapes := x.Shapes()
+	batch, steps := shapes[0], shapes[1]
+	if int64(len(lengths)) != batch {
+		panic(fmt.Errorf("got %d lengths for %d samples", len(lengths), batch))
+	}
+	data := make([]float32, batch*steps)
+	for i, n := range lengths {
+		if n < 0 || n > steps {
+			panic(fmt.Errorf("invalid length %d of sample %d, steps is %d", n, i, steps))
+		}
+		for j := int64(0); j < n; j++ {
+			data[int64(i)*steps+j] = 1
+		}
+	}
+	return tensor.FromFloat32(data,
+		tensor.WithShapes(batch, steps),
+		tensor.WithDevice(x.DeviceType())).ToScalarType(x.ScalarType())
+}
+
+// maskState keeps prev on the padded samples of the step
+func maskState(mask *tensor.Tensor, step int64, prev, next *tensor.Tensor) *tensor.Tensor {
+	if mask == nil {
+		return next
+	}
+	m := mask.NArrow(1, step, 1) // (batch, 1)
+	return prev.Add(m.Mul(next.Sub(prev)))
+}
+
+// maskOutput sets the output of the padded samples of the step to 0
+func maskOutput(mask *tensor.Tensor, step int64, y *tensor.Tensor) *tensor.Tensor {
+	if mask == nil {
+		return y
+	}
+	return y.Mul(mask.NArrow(1, step, 1))
+}
+
+// scan runs one layer in one direction, the states are not updated on padded steps
+// so the reverse direction begins at the last valid step of each sample
+func (r *recurrent) scan(x, mask *tensor.Tensor, states []*tensor.Tensor, reverse bool,
+	params []*tensor.Tensor, fn cellFunc) (*tensor.Tensor, []*tensor.Tensor) {
+	shapes := x.Shapes()
+	batch, steps := shapes[0], shapes[1]
+	result := make([]*tensor.Tensor, steps)
+	for i := int64(0); i < steps; i++ {
+		step := i
+		if reverse {
+			step = steps - 1 - i
+		}
+		t := x.NArrow(1, step, 1).Reshape(batch, shapes[2]) // (batch, feature)
+		next := fn(params, t, states)
+		for j := range states {
+			states[j] = maskState(mask, step, states[j], next[j])
+		}
+		result[step] = maskOutput(mask, step, next[0]).Unsqueeze(1) // (batch, 1, hidden)
+	}
+	return tensor.Cat(result, 1), states
+}
+
+// forward runs x (batch, steps, feature) through every layer and direction, states are the
+// initial states which can be nil, each state is (batch, hidden) for the single layer in one
+// direction and (numLayers*dirs, batch, hidden) otherwise, the returned states have the same shape
+func (r *recurrent) forward(x, mask *tensor.Tensor, states []*tensor.Tensor, train bool, fn cellFunc) (*tensor.Tensor, []*tensor.Tensor) {
+	batch := x.Shapes()[0]
+	dirs := r.dirs()
+	count := len(r.cells)
+	init := make([][]*tensor.Tensor, count) // init[cell][state]
+	for i := range init {
+		init[i] = make([]*tensor.Tensor, len(states))
+		for j, s := range states {
+			switch {
+			case s == nil:
+				init[i][j] = tensor.Zeros(r.scalarType(),
+					tensor.WithShapes(batch, int64(r.hidden)),
+					tensor.WithDevice(r.deviceType()))
+			case count == 1:
+				init[i][j] = s
+			default:
+				init[i][j] = s.NArrow(0, int64(i), 1).Squeeze(0)
+			}
+		}
+	}
+	final := make([][]*tensor.Tensor, len(states)) // final[state][cell]
+	for l := 0; l < r.numLayers; l++ {
+		if l > 0 && r.dropout > 0 {
+			x = x.Dropout(r.dropout, train)
+		}
+		outputs := make([]*tensor.Tensor, dirs)
+		for d := 0; d < dirs; d++ {
+			i := l*dirs + d
+			y, last := r.scan(x, mask, init[i], d == 1, r.cells[i], fn)
+			outputs[d] = y
+			for j, s := range last {
+				final[j] = append(final[j], s)
+			}
+		}
+		x = outputs[0]
+		if dirs > 1 {
+			x = tensor.Cat(outputs, 2) // (batch, steps, hidden*2)
+		}
+	}
+	ret := make([]*tensor.Tensor, len(states))
+	for j, list := range final {
+		if count == 1 {
+			ret[j] = list[0]
+		} else {
+			for i := range list {
+				list[i] = list[i].Unsqueeze(0)
+			}
+			ret[j] = tensor.Cat(list, 0)
+		}
+		// detach after stacking so every state is copied once for all layers and directions
+		if r.detachState {
+			ret[j] = detach(ret[j])
+		}
+	}
+	return x, ret
+}
+
+func (r *recurrent) Params() []*tensor.Tensor {
+	var ret []*tensor.Tensor
+	for _, cell := range r.cells {
+		ret = append(ret, cell...)
+	}
+	return ret
+}
+
+func (r *recurrent) SetParams(params []*tensor.Tensor) {
+	var dst []**tensor.Tensor
+	for _, cell := range r.cells {
+		for i := range cell {
+			dst = append(dst, &cell[i])
+		}
+	}
+	setParams(params, dst...)
+}
+
+// ParamNames names the params of the first layer in forward direction as before,
+// others are suffixed by the layer index and _reverse for the reverse direction like PyTorch
+func (r *recurrent) ParamNames() []string {
+	dirs := r.dirs()
+	var ret []string
+	for i := range r.cells {
+		var suffix string
+		if i > 0 {
+			suffix = fmt.Sprintf("_l%d", i/dirs)
+			if i%dirs == 1 {
+				suffix += "_reverse"
+			}
+		}
+		for _, name := range r.cellNames {
+			ret = append(ret, name+suffix)
+		}
+	}
+	return ret
+}
+
+func (r *recurrent) Args() map[string]float32 {
+	var bidirectional, detachState float32
+	if r.bidirectional {
+		bidirectional = 1
+	}
+	if r.detachState {
+		detachState = 1
+	}
+	return map[string]float32{
+		"feature_size":  float32(r.featureSize),
+		"steps":         float32(r.steps),
+		"hidden":        float32(r.hidden),
+		"num_layers":    float32(r.numLayers),
+		"bidirectional": bidirectional,
+		"dropout":       float32(r.dropout),
+		"detach_state":  detachState,
+	}
+}
+
+func (r *recurrent) Freeze() {
+	for _, p := range r.Params() {
+		p.SetRequiresGrad(false)
+	}
+}
+
+func (r *recurrent) Unfreeze() {
+	for _, p := range r.Params() {
+		p.SetRequiresGrad(true)
+	}
+}
+
+func (r *recurrent) ToScalarType(t consts.ScalarType) {
+	for _, cell := range r.cells {
+		for i, p := range cell {
+			cell[i] = p.ToScalarType(t)
+		}
+	}
+}
+
+func (r *recurrent) Reset() {
+	for _, cell := range r.cells {
+		for i, p := range cell {
+			if p.Dims() == 1 {
+				cell[i] = r.initB(p.Shapes()...)
+			} else {
+				cell[i] = r.initW(p.Shapes()...)
+			}
+		}
+	}
+}
@@ -30,12 +30,26 @@ func (layer *ReZero) Forward(x *tensor.Tensor) *tensor.Tensor {
 	return x.Mul(layer.scale)
 }
 
+func (layer *ReZero) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
 func (layer *ReZero) Params() []*tensor.Tensor {
 	return []*tensor.Tensor{
 		layer.scale,
 	}
 }
 
+func (layer *ReZero) SetParams(params []*tensor.Tensor) {
+	setParams(params, &layer.scale)
+}
+
+func (layer *ReZero) ParamNames() []string {
+	return []string{
+		"scale",
+	}
+}
+
 func (layer *ReZero) Args() map[string]float32 {
 	return map[string]float32{}
 }
@@ -7,7 +7,8 @@ import (
 
 type RMSNorm struct {
 	base
-	eps *tensor.Tensor
+	epsValue float64
+	eps      *tensor.Tensor
 	// params
 	a *tensor.Tensor
 }
@@ -15,7 +16,8 @@ type RMSNorm struct {
 func NewRMSNorm(name string, dims int64, opts ...LayerCreateOption) *RMSNorm {
 	var layer RMSNorm
 	layer.new("rms_norm", name, opts...)
-	layer.eps = layer.initN(1e-9)
+	layer.epsValue = 1e-9
+	layer.eps = layer.initN(layer.epsValue)
 	layer.a = layer.ones(dims)
 	layer.a.SetRequiresGrad(true)
 	return &layer
@@ -25,11 +27,20 @@ func LoadRMSNorm(name string, params []*tensor.Tensor, args map[string]float32)
 	var layer RMSNorm
 	layer.new("rms_norm", name)
 	layer.paramType = params[0].ScalarType()
-	layer.eps = layer.initN(1e-9)
+	layer.epsValue = 1e-9
+	if eps, ok := args["eps"]; ok {
+		layer.epsValue = float64(eps)
+	}
+	layer.eps = layer.initN(layer.epsValue)
 	layer.a = params[0]
 	return &layer
 }
 
+func (layer *RMSNorm) SetEps(eps float64) {
+	layer.epsValue = eps
+	layer.eps = layer.initN(eps)
+}
+
 func (l *RMSNorm) norm(x *tensor.Tensor) *tensor.Tensor {
 	return x.Mul(x.Pow(2).Mean(-1, true).Add(l.eps.ToDevice(x.DeviceType())).RSqrt())
 }
@@ -38,12 +49,32 @@ func (layer *RMSNorm) Forward(x *tensor.Tensor) *tensor.Tensor {
 	return layer.a.Mul(layer.norm(x))
 }
 
+func (layer *RMSNorm) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
 func (layer *RMSNorm) Params() []*tensor.Tensor {
 	return []*tensor.Tensor{
 		layer.a,
 	}
 }
 
+func (layer *RMSNorm) SetParams(params []*tensor.Tensor) {
+	setParams(params, &layer.a)
+}
+
+func (layer *RMSNorm) ParamNames() []string {
+	return []string{
+		"a",
+	}
+}
+
+func (layer *RMSNorm) Args() map[string]float32 {
+	return map[string]float32{
+		"eps": float32(layer.epsValue),
+	}
+}
+
 func (layer *RMSNorm) Freeze() {
 	layer.a.SetRequiresGrad(false)
 }
@@ -1,99 +1,81 @@
 package layer
 
 import (
-	"github.com/lwch/gotorch/consts"
 	"github.com/lwch/gotorch/tensor"
 )
 
 type Rnn struct {
-	base
-	featureSize, steps int
-	hidden             int
-	// params
-	w *tensor.Tensor
-	b *tensor.Tensor
+	recurrent
 }
 
+func newRnnCell(r *recurrent, input int64) []*tensor.Tensor {
+	return []*tensor.Tensor{
+		r.initW(input+int64(r.hidden), int64(r.hidden)), // w
+		r.initB(int64(r.hidden)),                        // b
+	}
+}
+
+func rnnCell(params []*tensor.Tensor, x *tensor.Tensor, states []*tensor.Tensor) []*tensor.Tensor {
+	z := tensor.HStack(x, states[0])       // (batch, feature+hidden)
+	z = z.MatMul(params[0]).Add(params[1]) // (batch, hidden)
+	return []*tensor.Tensor{z.Tanh()}
+}
+
+var rnnParamNames = []string{"w", "b"}
+
+// NewRnn creates rnn layer, steps is only saved in Args,
+// Forward infers the count of steps from the input
 func NewRnn(name string, featureSize, steps, hidden int, opts ...LayerCreateOption) *Rnn {
 	var layer Rnn
 	layer.new("rnn", name, opts...)
-	layer.featureSize = featureSize
-	layer.steps = steps
-	layer.hidden = hidden
-	layer.w = layer.initW(int64(featureSize+layer.hidden), int64(hidden))
-	layer.b = layer.initB(int64(hidden))
+	layer.setup(featureSize, steps, hidden, rnnParamNames, newRnnCell)
 	return &layer
 }
 
 func LoadRnn(name string, params []*tensor.Tensor, args map[string]float32) Layer {
 	var layer Rnn
 	layer.new("rnn", name)
-	layer.featureSize = int(args["feature_size"])
-	layer.steps = int(args["steps"])
-	layer.hidden = int(args["hidden"])
-	layer.w = params[0]
-	layer.b = params[1]
+	layer.load(params, args, rnnParamNames, newRnnCell)
 	return &layer
 }
 
-func copyState(s *tensor.Tensor) *tensor.Tensor {
-	return tensor.FromFloat32(s.Float32Value(), tensor.WithShapes(s.Shapes()...))
-}
-
+// Forward runs without the dropout between layers, use ForwardMask to train with dropout
 func (layer *Rnn) Forward(x, h *tensor.Tensor) (*tensor.Tensor, *tensor.Tensor) {
-	inputShape := x.Shapes()
-	if h == nil {
-		h = tensor.Zeros(consts.KFloat, tensor.WithShapes(inputShape[0], int64(layer.hidden)))
-	}
-	x = x.Transpose(1, 0) // (steps, batch, feature)
-	var result *tensor.Tensor
-	for step := 0; step < layer.steps; step++ {
-		t := x.NArrow(0, int64(step), 1).
-			Reshape(int64(inputShape[0]), int64(layer.featureSize)) // (batch, feature)
-		z := tensor.HStack(t, h)           // (batch, feature+hidden)
-		z = z.MatMul(layer.w).Add(layer.b) // (batch, hidden)
-		h = z.Tanh()                       // (batch, hidden)
-		if result == nil {
-			result = h
-		} else {
-			result = tensor.VStack(result, h)
-		}
-	}
-	return result.Reshape(inputShape[0], inputShape[1], int64(layer.hidden)),
-		copyState(h)
-}
-
-func (layer *Rnn) Params() []*tensor.Tensor {
-	return []*tensor.Tensor{
-		layer.w,
-		layer.b,
-	}
+	return layer.ForwardMask(x, nil, h, false)
 }
 
-func (layer *Rnn) Args() map[string]float32 {
-	return map[string]float32{
-		"feature_size": float32(layer.featureSize),
-		"steps":        float32(layer.steps),
-		"hidden":       float32(layer.hidden),
-	}
-}
-
-func (layer *Rnn) Freeze() {
-	layer.w.SetRequiresGrad(false)
-	layer.b.SetRequiresGrad(false)
+// ForwardLengths runs the first lengths[i] steps of sample i, see ForwardMask
+func (layer *Rnn) ForwardLengths(x *tensor.Tensor, lengths []int64, h *tensor.Tensor, train bool) (*tensor.Tensor, *tensor.Tensor) {
+	return layer.ForwardMask(x, lengthMask(x, lengths), h, train)
 }
 
-func (layer *Rnn) Unfreeze() {
-	layer.w.SetRequiresGrad(true)
-	layer.b.SetRequiresGrad(true)
+// ForwardMask runs x (batch, steps, feature) with the count of steps inferred from x,
+// mask (batch, steps) is 1 on valid steps and 0 on padded steps, the hidden state is not
+// updated on padded steps so the returned state is the one of each sample's last valid step,
+// the output of padded steps is 0. mask can be nil.
+//
+// The hidden state is (batch, hidden) for the single layer in one direction and
+// (num_layers*dirs, batch, hidden) otherwise
+func (layer *Rnn) ForwardMask(x, mask, h *tensor.Tensor, train bool) (*tensor.Tensor, *tensor.Tensor) {
+	y, states := layer.forward(x, mask, []*tensor.Tensor{h}, train, rnnCell)
+	return y, states[0]
 }
 
-func (layer *Rnn) ToScalarType(t consts.ScalarType) {
-	layer.w = layer.w.ToScalarType(t)
-	layer.b = layer.b.ToScalarType(t)
+func (layer *Rnn) Call(x *tensor.Tensor, train bool) *tensor.Tensor {
+	y, _ := layer.ForwardMask(x, nil, nil, train)
+	return y
 }
 
-func (layer *Rnn) Reset() {
-	layer.w = layer.initW(layer.w.Shapes()...)
-	layer.b = layer.initB(layer.b.Shapes()...)
+// CallMulti inputs are x, an optional hidden state and an optional mask (batch, steps),
+// outputs are y and hidden state
+func (layer *Rnn) CallMulti(train bool, xs ...*tensor.Tensor) []*tensor.Tensor {
+	var h, mask *tensor.Tensor
+	if len(xs) > 1 {
+		h = xs[1]
+	}
+	if len(xs) > 2 {
+		mask = xs[2]
+	}
+	y, h := layer.ForwardMask(xs[0], mask, h, train)
+	return []*tensor.Tensor{y, h}
 }
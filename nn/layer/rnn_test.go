@@ -0,0 +1,108 @@
+package layer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+func assertClose(t *testing.T, name string, a, b []float32) {
+	assertCloseTol(t, name, a, b, 1e-6)
+}
+
+func assertCloseTol(t *testing.T, name string, a, b []float32, tol float64) {
+	if len(a) != len(b) {
+		t.Fatalf("%s: unexpected size %d, expected %d", name, len(a), len(b))
+	}
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > tol {
+			t.Fatalf("%s: unexpected value at %d: %f != %f", name, i, a[i], b[i])
+		}
+	}
+}
+
+func TestRnnLengths(t *testing.T) {
+	x := tensor.FromFloat32([]float32{
+		1, 2, 3, 4, 5, 6, 7, 8,
+		8, 7, 6, 5, 4, 3, 2, 1,
+	}, tensor.WithShapes(2, 4, 2))
+	rnn := NewRnn("rnn", 2, 4, 3)
+	y, h := rnn.ForwardLengths(x, []int64{2, 4}, nil, false)
+	if shapes := y.Shapes(); shapes[0] != 2 || shapes[1] != 4 || shapes[2] != 3 {
+		t.Fatalf("unexpected output shape %v", shapes)
+	}
+	// the state of the first sample is the one after its second step
+	y0, h0 := rnn.Forward(x.NArrow(0, 0, 1).NArrow(1, 0, 2), nil)
+	assertClose(t, "rnn hidden", h.NArrow(0, 0, 1).Contiguous().Float32Value(), h0.Float32Value())
+	assertClose(t, "rnn output", y.NArrow(0, 0, 1).NArrow(1, 0, 2).Contiguous().Float32Value(), y0.Float32Value())
+	assertClose(t, "rnn padding", y.NArrow(0, 0, 1).NArrow(1, 2, 2).Contiguous().Float32Value(), make([]float32, 6))
+	_, h1 := rnn.Forward(x.NArrow(0, 1, 1), nil)
+	assertClose(t, "rnn full", h.NArrow(0, 1, 1).Contiguous().Float32Value(), h1.Float32Value())
+
+	lstm := NewLstm("lstm", 2, 4, 3)
+	_, h, c := lstm.ForwardLengths(x, []int64{2, 4}, nil, nil, false)
+	_, h0, c0 := lstm.Forward(x.NArrow(0, 0, 1).NArrow(1, 0, 2), nil, nil)
+	assertClose(t, "lstm hidden", h.NArrow(0, 0, 1).Contiguous().Float32Value(), h0.Float32Value())
+	assertClose(t, "lstm cell", c.NArrow(0, 0, 1).Contiguous().Float32Value(), c0.Float32Value())
+}
+
+func TestRecurrentStack(t *testing.T) {
+	x := tensor.FromFloat32([]float32{
+		1, 2, 3, 4, 5, 6, 7, 8,
+		8, 7, 6, 5, 4, 3, 2, 1,
+	}, tensor.WithShapes(2, 4, 2))
+	gru := NewGru("gru", 2, 4, 3)
+	gru.SetNumLayers(2)
+	gru.SetBidirectional(true)
+	gru.SetDropout(0.1)
+	if n := len(gru.Params()); n != 4*8 {
+		t.Fatalf("unexpected params count %d", n)
+	}
+	y, h := gru.ForwardLengths(x, []int64{3, 4}, nil, false)
+	if shapes := y.Shapes(); shapes[0] != 2 || shapes[1] != 4 || shapes[2] != 6 {
+		t.Fatalf("unexpected output shape %v", shapes)
+	}
+	if shapes := h.Shapes(); shapes[0] != 4 || shapes[1] != 2 || shapes[2] != 3 {
+		t.Fatalf("unexpected hidden shape %v", shapes)
+	}
+	names := gru.ParamNames()
+	if names[0] != "wr" || names[8] != "wr_l0_reverse" || names[16] != "wr_l1" {
+		t.Fatalf("unexpected param names %v", names)
+	}
+
+	loaded := LoadGru("gru", gru.Params(), gru.Args()).(*Gru)
+	y2, h2 := loaded.ForwardLengths(x, []int64{3, 4}, h, false)
+	y, _ = gru.ForwardLengths(x, []int64{3, 4}, h, false)
+	assertClose(t, "gru output", y.Float32Value(), y2.Float32Value())
+	if shapes := h2.Shapes(); shapes[0] != 4 {
+		t.Fatalf("unexpected hidden shape %v", shapes)
+	}
+
+	lstm := NewLstm("lstm", 2, 4, 3)
+	lstm.SetNumLayers(3)
+	_, h, c := lstm.Forward(x, nil, nil)
+	if h.Shapes()[0] != 3 || c.Shapes()[0] != 3 {
+		t.Fatalf("unexpected state shape %v %v", h.Shapes(), c.Shapes())
+	}
+}
+
+func TestRecurrentScalarType(t *testing.T) {
+	x := tensor.FromFloat64([]float64{1, 2, 3, 4, 5, 6}, tensor.WithShapes(1, 3, 2))
+	lstm := NewLstm("lstm", 2, 3, 4, WithParamType(consts.KDouble))
+	y, h, c := lstm.Forward(x, nil, nil)
+	for _, s := range []*tensor.Tensor{y, h, c} {
+		if s.ScalarType() != consts.KDouble {
+			t.Fatalf("unexpected scalar type %s", s.ScalarType().String())
+		}
+	}
+	lstm.SetDetachState(false)
+	_, h, _ = lstm.Forward(x, nil, nil)
+	if h.ScalarType() != consts.KDouble {
+		t.Fatalf("unexpected scalar type %s", h.ScalarType().String())
+	}
+	if LoadLstm("lstm", lstm.Params(), lstm.Args()).Args()["detach_state"] != 0 {
+		t.Fatal("detach_state is not saved")
+	}
+}
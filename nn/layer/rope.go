@@ -0,0 +1,59 @@
+package layer
+
+import "math"
+
+// ROPEScaling is the method to extend the context length of RoPE
+type ROPEScaling int
+
+const (
+	ROPEScalingNone ROPEScaling = iota
+	// ROPEScalingLinear interpolates the positions by 1/factor
+	ROPEScalingLinear
+	// ROPEScalingNTK scales the base by factor^(dim/(dim-2)), the high frequencies are almost kept
+	ROPEScalingNTK
+	// ROPEScalingYaRN interpolates the low frequencies only and scales the attention by 0.1*ln(factor)+1
+	ROPEScalingYaRN
+)
+
+const (
+	yarnBetaFast = 32
+	yarnBetaSlow = 1
+)
+
+type ropeScaling struct {
+	kind     ROPEScaling
+	factor   float64
+	original int64 // context length of training, used by YaRN
+}
+
+// invFreqs returns the inverse frequency of each pair of dims and the magnitude of the rotation
+func (s ropeScaling) invFreqs(base, dim int64) ([]float64, float64) {
+	b := float64(base)
+	if s.kind == ROPEScalingNTK {
+		b *= math.Pow(s.factor, float64(dim)/float64(dim-2))
+	}
+	ret := make([]float64, dim/2)
+	for i := range ret {
+		ret[i] = 1 / math.Pow(b, float64(2*i)/float64(dim))
+	}
+	mscale := 1.0
+	switch s.kind {
+	case ROPEScalingLinear:
+		for i := range ret {
+			ret[i] /= s.factor
+		}
+	case ROPEScalingYaRN:
+		for i, freq := range ret {
+			// count of rotations in the original context, the dims rotating more than
+			// betaFast times keep their frequency and the ones less than betaSlow are interpolated
+			rotations := float64(s.original) * freq / (2 * math.Pi)
+			keep := (rotations - yarnBetaSlow) / (yarnBetaFast - yarnBetaSlow)
+			keep = math.Max(0, math.Min(1, keep))
+			ret[i] = freq/s.factor*(1-keep) + freq*keep
+		}
+		if s.factor > 1 {
+			mscale = 0.1*math.Log(s.factor) + 1
+		}
+	}
+	return ret, mscale
+}
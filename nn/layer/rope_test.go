@@ -0,0 +1,40 @@
+package layer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestROPEScaling(t *testing.T) {
+	const dim = 64
+	none, _ := ropeScaling{}.invFreqs(10000, dim)
+
+	linear, _ := ropeScaling{kind: ROPEScalingLinear, factor: 4}.invFreqs(10000, dim)
+	for i := range none {
+		if math.Abs(linear[i]*4-none[i]) > 1e-12 {
+			t.Fatalf("linear: unexpected freq %d", i)
+		}
+	}
+
+	ntk, _ := ropeScaling{kind: ROPEScalingNTK, factor: 4}.invFreqs(10000, dim)
+	if ntk[0] != none[0] || math.Abs(ntk[dim/2-1]*4-none[dim/2-1]) > 1e-9 {
+		t.Fatalf("ntk: unexpected freqs %f, %f", ntk[0], ntk[dim/2-1])
+	}
+
+	yarn, mscale := ropeScaling{kind: ROPEScalingYaRN, factor: 4, original: 2048}.invFreqs(10000, dim)
+	if yarn[0] != none[0] || math.Abs(yarn[dim/2-1]*4-none[dim/2-1]) > 1e-12 {
+		t.Fatalf("yarn: unexpected freqs %f, %f", yarn[0], yarn[dim/2-1])
+	}
+	if math.Abs(mscale-(0.1*math.Log(4)+1)) > 1e-12 {
+		t.Fatalf("yarn: unexpected mscale %f", mscale)
+	}
+}
+
+func TestAttentionROPEScalingArgs(t *testing.T) {
+	l := NewAttention1("attn1", 8, 2, 0, true)
+	l.SetROPEScaling(ROPEScalingYaRN, 4, 2048)
+	loaded := LoadAttention1("attn1", l.Params(), l.Args()).(*Attention1)
+	if loaded.ropeScaling != l.ropeScaling {
+		t.Fatalf("unexpected rope scaling: %v", loaded.ropeScaling)
+	}
+}
@@ -0,0 +1,130 @@
+package layer
+
+import (
+	"fmt"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+// SeparableConv2D is the depthwise separable convolution,
+// a depthwise Conv2D with inC groups followed by a pointwise 1x1 Conv2D
+type SeparableConv2D struct {
+	base
+	depthwise *Conv2D
+	pointwise *Conv2D
+}
+
+func NewSeparableConv2D(name string, inC, outC int, kernel1, kernel2 int, opts ...LayerCreateOption) *SeparableConv2D {
+	var layer SeparableConv2D
+	layer.new("separable_conv2d", name, opts...)
+	layer.depthwise = NewConv2D(name, inC, inC, kernel1, kernel2, opts...)
+	layer.depthwise.SetGroups(inC)
+	layer.pointwise = NewConv2D(name, inC, outC, 1, 1, opts...)
+	return &layer
+}
+
+func (layer *SeparableConv2D) SetStride(stride1, stride2 int) {
+	layer.depthwise.SetStride(stride1, stride2)
+}
+
+func (layer *SeparableConv2D) SetPadding(padding1, padding2 int) {
+	layer.depthwise.SetPadding(padding1, padding2)
+}
+
+func (layer *SeparableConv2D) SetSamePadding(same bool) {
+	layer.depthwise.SetSamePadding(same)
+}
+
+func (layer *SeparableConv2D) SetDilation(dilation1, dilation2 int) {
+	layer.depthwise.SetDilation(dilation1, dilation2)
+}
+
+func LoadSeparableConv2D(name string, params []*tensor.Tensor, args map[string]float32) Layer {
+	var layer SeparableConv2D
+	layer.new("separable_conv2d", name)
+	inC := args["inC"]
+	depthwise := make(map[string]float32, len(args))
+	for k, v := range args {
+		depthwise[k] = v
+	}
+	depthwise["outC"] = inC
+	depthwise["groups"] = inC
+	n := 1
+	if args["bias"] != 0 {
+		n = 2
+	}
+	layer.depthwise = LoadConv2D(name, params[:n], depthwise).(*Conv2D)
+	layer.pointwise = LoadConv2D(name, params[n:], map[string]float32{
+		"inC":       inC,
+		"outC":      args["outC"],
+		"kernel1":   1,
+		"kernel2":   1,
+		"stride1":   1,
+		"stride2":   1,
+		"dilation1": 1,
+		"dilation2": 1,
+		"groups":    1,
+		"bias":      args["bias"],
+	}).(*Conv2D)
+	return &layer
+}
+
+func (layer *SeparableConv2D) Forward(x *tensor.Tensor) *tensor.Tensor {
+	return layer.pointwise.Forward(layer.depthwise.Forward(x))
+}
+
+func (layer *SeparableConv2D) Call(x *tensor.Tensor, _ bool) *tensor.Tensor {
+	return layer.Forward(x)
+}
+
+func (layer *SeparableConv2D) Params() []*tensor.Tensor {
+	return append(layer.depthwise.Params(), layer.pointwise.Params()...)
+}
+
+func (layer *SeparableConv2D) SetParams(params []*tensor.Tensor) {
+	n := len(layer.depthwise.Params())
+	if len(params) < n {
+		panic(fmt.Errorf("expected %d params, got %d", n+len(layer.pointwise.Params()), len(params)))
+	}
+	layer.depthwise.SetParams(params[:n])
+	layer.pointwise.SetParams(params[n:])
+}
+
+func (layer *SeparableConv2D) ParamNames() []string {
+	var names []string
+	for _, name := range layer.depthwise.ParamNames() {
+		names = append(names, "depthwise."+name)
+	}
+	for _, name := range layer.pointwise.ParamNames() {
+		names = append(names, "pointwise."+name)
+	}
+	return names
+}
+
+func (layer *SeparableConv2D) Args() map[string]float32 {
+	args := layer.depthwise.Args()
+	args["outC"] = float32(layer.pointwise.outC)
+	delete(args, "groups")
+	return args
+}
+
+func (layer *SeparableConv2D) Freeze() {
+	layer.depthwise.Freeze()
+	layer.pointwise.Freeze()
+}
+
+func (layer *SeparableConv2D) Unfreeze() {
+	layer.depthwise.Unfreeze()
+	layer.pointwise.Unfreeze()
+}
+
+func (layer *SeparableConv2D) ToScalarType(t consts.ScalarType) {
+	layer.depthwise.ToScalarType(t)
+	layer.pointwise.ToScalarType(t)
+}
+
+func (layer *SeparableConv2D) Reset() {
+	layer.depthwise.Reset()
+	layer.pointwise.Reset()
+}
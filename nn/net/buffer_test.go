@@ -0,0 +1,45 @@
+package net
+
+import (
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/nn/layer"
+)
+
+func TestBufferRoundTrip(t *testing.T) {
+	bn := layer.NewBatchNorm1D("bn", 2)
+	x := tensor.ARange(4*2, consts.KFloat).Reshape(4, 2)
+	bn.Forward(x, true)
+
+	n := New(consts.KCPU)
+	n.Add(bn)
+	if len(n.Params()) != 2 {
+		t.Fatalf("buffers should not be in params, got %d params", len(n.Params()))
+	}
+	loaded := roundTrip(t, n).Layers()[0].(*layer.BatchNorm1D)
+	want := bn.Forward(x, false).Float32Value()
+	got := loaded.Forward(x, false).Float32Value()
+	for i := range want {
+		if want[i] != got[i] {
+			t.Fatalf("unexpected value %d: %f != %f", i, got[i], want[i])
+		}
+	}
+
+	dict := n.StateDict()
+	if dict["bn.running_mean"] == nil || dict["bn.running_var"] == nil {
+		t.Fatal("missing buffers in state dict")
+	}
+	target := New(consts.KCPU)
+	target.Add(layer.NewBatchNorm1D("bn", 2))
+	if _, err := target.LoadStateDict(dict, true); err != nil {
+		t.Fatal(err)
+	}
+	got = target.Layers()[0].(*layer.BatchNorm1D).Forward(x, false).Float32Value()
+	for i := range want {
+		if want[i] != got[i] {
+			t.Fatalf("unexpected value %d after LoadStateDict: %f != %f", i, got[i], want[i])
+		}
+	}
+}
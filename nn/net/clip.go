@@ -0,0 +1,24 @@
+package net
+
+import (
+	"github.com/lwch/gotorch/tensor"
+)
+
+// ClipGradNorm scales the gradients of params in place so that their total norm is at most maxNorm,
+// normType is 2 for the euclidean norm and math.Inf(1) for the max norm. Params with different
+// scalar types (e.g. created by layer.WithParamType) are promoted when computing the total norm,
+// params without gradient are skipped.
+//
+// gotorch does not expose the gradients to go, so the total norm before clipping can not be
+// returned and clipping by value is not supported yet.
+func ClipGradNorm(params []*tensor.Tensor, maxNorm, normType float64) {
+	if len(params) == 0 {
+		return
+	}
+	tensor.ClipGradNorm(params, maxNorm, normType)
+}
+
+// ClipGradNorm clips the gradients of all params in the net, see ClipGradNorm
+func (n *Net) ClipGradNorm(maxNorm, normType float64) {
+	ClipGradNorm(n.Params(), maxNorm, normType)
+}
@@ -0,0 +1,58 @@
+package net
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrUnknownLayer          = errors.New("unknown layer")
+	ErrUnknownOptimizer      = errors.New("unknown optimizer")
+	ErrInvalidOptimizer      = errors.New("invalid optimizer")
+	ErrUnknownScheduler      = errors.New("unknown scheduler")
+	ErrUnsupportedScalarType = errors.New("unsupported scalar type")
+	ErrCorruptParam          = errors.New("corrupt param")
+	ErrInvalidLayer          = errors.New("invalid layer")
+)
+
+// CorruptParamError is returned when the param file does not match its definition,
+// it matches ErrCorruptParam by errors.Is
+type CorruptParamError struct {
+	File     string
+	Expected int64 // expected bytes
+	Actual   int64 // actual bytes
+}
+
+func (e *CorruptParamError) Error() string {
+	return fmt.Sprintf("corrupt param %s: expected %d bytes, got %d bytes",
+		e.File, e.Expected, e.Actual)
+}
+
+func (e *CorruptParamError) Is(target error) bool {
+	return target == ErrCorruptParam
+}
+
+// group runs functions in goroutines and keeps the first error
+type group struct {
+	wg   sync.WaitGroup
+	once sync.Once
+	err  error
+}
+
+func (g *group) Go(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := fn(); err != nil {
+			g.once.Do(func() {
+				g.err = err
+			})
+		}
+	}()
+}
+
+func (g *group) Wait() error {
+	g.wg.Wait()
+	return g.err
+}
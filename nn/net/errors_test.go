@@ -0,0 +1,147 @@
+package net
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/tnn/internal/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func buildModel(t *testing.T, spec *pb.Net, files map[string][]byte) *bytes.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	data, err := proto.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["SPEC"] = data
+	for name, data := range files {
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:   name,
+			Method: zip.Store,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = f.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestReadUnknownLayer(t *testing.T) {
+	r := buildModel(t, &pb.Net{
+		Layers: []*pb.Layer{{Class: "unknown", Name: "unknown"}},
+	}, map[string][]byte{})
+	var net Net
+	_, err := net.ReadFrom(r, r.Size())
+	if !errors.Is(err, ErrUnknownLayer) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestReadCorruptParam(t *testing.T) {
+	r := buildModel(t, &pb.Net{
+		Layers: []*pb.Layer{{
+			Class: "linear",
+			Name:  "linear",
+			Params: []*pb.Param{{
+				Type:      uint32(consts.KFloat),
+				ElemCount: 6,
+				Shapes:    []int64{3, 2},
+				File:      "layer_0_param_0.bin",
+			}},
+			Args: map[string]float32{"output": 3},
+		}},
+	}, map[string][]byte{
+		"layer_0_param_0.bin": make([]byte, 10),
+	})
+	var net Net
+	_, err := net.ReadFrom(r, r.Size())
+	var e *CorruptParamError
+	if !errors.As(err, &e) || !errors.Is(err, ErrCorruptParam) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Expected != 24 || e.Actual != 10 {
+		t.Fatalf("unexpected size: expected=%d, actual=%d", e.Expected, e.Actual)
+	}
+}
+
+func TestReadInvalidShapes(t *testing.T) {
+	read := func(cnt int64, shapes []int64) error {
+		r := buildModel(t, &pb.Net{
+			Layers: []*pb.Layer{{
+				Class: "linear",
+				Name:  "linear",
+				Params: []*pb.Param{{
+					Type:      uint32(consts.KFloat),
+					ElemCount: cnt,
+					Shapes:    shapes,
+					File:      "layer_0_param_0.bin",
+				}},
+				Args: map[string]float32{"output": 3},
+			}},
+		}, map[string][]byte{
+			"layer_0_param_0.bin": make([]byte, 24),
+		})
+		var net Net
+		_, err := net.ReadFrom(r, r.Size())
+		return err
+	}
+	for _, c := range []struct {
+		name   string
+		cnt    int64
+		shapes []int64
+	}{
+		{"negative dims", 6, []int64{-2, -3}},
+		{"overflow", 0, []int64{1 << 32, 1 << 32}},
+		{"count mismatch", 7, []int64{3, 2}},
+	} {
+		if err := read(c.cnt, c.shapes); !errors.Is(err, ErrCorruptParam) {
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		}
+	}
+	// the size is checked against the file before allocating
+	err := read(1<<40, []int64{1 << 40})
+	var e *CorruptParamError
+	if !errors.As(err, &e) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Expected != 1<<42 || e.Actual != 24 {
+		t.Fatalf("unexpected size: expected=%d, actual=%d", e.Expected, e.Actual)
+	}
+}
+
+func TestReadUnknownLayerAfterValid(t *testing.T) {
+	r := buildModel(t, &pb.Net{
+		Layers: []*pb.Layer{{
+			Class: "linear",
+			Name:  "linear",
+			Params: []*pb.Param{{
+				Type:      uint32(consts.KFloat),
+				ElemCount: 6,
+				Shapes:    []int64{3, 2},
+				File:      "layer_0_param_0.bin",
+			}},
+			Args: map[string]float32{"output": 3},
+		}, {Class: "unknown", Name: "unknown"}},
+	}, map[string][]byte{
+		"layer_0_param_0.bin": make([]byte, 24),
+	})
+	var net Net
+	_, err := net.ReadFrom(r, r.Size())
+	if !errors.Is(err, ErrUnknownLayer) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(net.Layers()) != 0 {
+		t.Fatal("layers should not be loaded")
+	}
+}
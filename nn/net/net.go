@@ -6,56 +6,101 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/klauspost/compress/zstd"
 	"github.com/lwch/gotorch/consts"
 	"github.com/lwch/gotorch/optimizer"
 	"github.com/lwch/gotorch/tensor"
-	"github.com/lwch/runtime"
 	"github.com/lwch/tnn/internal/pb"
 	"github.com/lwch/tnn/nn/layer"
 	"github.com/lwch/tnn/nn/layer/activation"
+	"github.com/lwch/tnn/nn/scheduler"
 	"google.golang.org/protobuf/proto"
 )
 
 type loadFunc func(name string, params []*tensor.Tensor, args map[string]float32) layer.Layer
 
 var loadFuncs = map[string]loadFunc{
-	"linear":          layer.LoadLinear,
-	"dropout":         layer.LoadDropout,
-	"conv1d":          layer.LoadConv1D,
-	"conv2d":          layer.LoadConv2D,
-	"maxpool1d":       layer.LoadMaxPool1D,
-	"convtranspose1d": layer.LoadConvTranspose1D,
-	"convtranspose2d": layer.LoadConvTranspose2D,
-	"rnn":             layer.LoadRnn,
-	"lstm":            layer.LoadLstm,
-	"attention":       layer.LoadAttention,
-	"attention1":      layer.LoadAttention1,
-	"layer_norm":      layer.LoadLayerNorm,
-	"rms_norm":        layer.LoadRMSNorm,
-	"flatten":         layer.LoadFlatten,
-	"embedding":       layer.LoadEmbedding,
-	"rezero":          layer.LoadReZero,
+	"linear":              layer.LoadLinear,
+	"dropout":             layer.LoadDropout,
+	"conv1d":              layer.LoadConv1D,
+	"conv2d":              layer.LoadConv2D,
+	"conv3d":              layer.LoadConv3D,
+	"separable_conv2d":    layer.LoadSeparableConv2D,
+	"maxpool1d":           layer.LoadMaxPool1D,
+	"maxpool2d":           layer.LoadMaxPool2D,
+	"avgpool1d":           layer.LoadAvgPool1D,
+	"avgpool2d":           layer.LoadAvgPool2D,
+	"adaptive_avgpool1d":  layer.LoadAdaptiveAvgPool1D,
+	"adaptive_maxpool1d":  layer.LoadAdaptiveMaxPool1D,
+	"adaptive_avgpool2d":  layer.LoadAdaptiveAvgPool2D,
+	"adaptive_maxpool2d":  layer.LoadAdaptiveMaxPool2D,
+	"global_avgpool":      layer.LoadGlobalAvgPool,
+	"global_maxpool":      layer.LoadGlobalMaxPool,
+	"convtranspose1d":     layer.LoadConvTranspose1D,
+	"convtranspose2d":     layer.LoadConvTranspose2D,
+	"convtranspose3d":     layer.LoadConvTranspose3D,
+	"rnn":                 layer.LoadRnn,
+	"lstm":                layer.LoadLstm,
+	"gru":                 layer.LoadGru,
+	"attention":           layer.LoadAttention,
+	"attention1":          layer.LoadAttention1,
+	"layer_norm":          layer.LoadLayerNorm,
+	"rms_norm":            layer.LoadRMSNorm,
+	"batch_norm1d":        layer.LoadBatchNorm1D,
+	"batch_norm2d":        layer.LoadBatchNorm2D,
+	"group_norm":          layer.LoadGroupNorm,
+	"instance_norm":       layer.LoadInstanceNorm,
+	"flatten":             layer.LoadFlatten,
+	"embedding":           layer.LoadEmbedding,
+	"rezero":              layer.LoadReZero,
+	"prelu":               layer.LoadPReLU,
+	"feed_forward":        layer.LoadFeedForward,
+	"sinusoidal_encoding": layer.LoadSinusoidalEncoding,
+	"position_embedding":  layer.LoadPositionEmbedding,
 	// activation
-	"sigmoid": activation.LoadSigmoid,
-	"tanh":    activation.LoadTanh,
-	"relu":    activation.LoadRelu,
-	"gelu":    activation.LoadGelu,
+	"sigmoid":     activation.LoadSigmoid,
+	"tanh":        activation.LoadTanh,
+	"relu":        activation.LoadRelu,
+	"gelu":        activation.LoadGelu,
+	"leaky_relu":  activation.LoadLeakyRelu,
+	"elu":         activation.LoadElu,
+	"selu":        activation.LoadSelu,
+	"silu":        activation.LoadSilu,
+	"mish":        activation.LoadMish,
+	"softplus":    activation.LoadSoftplus,
+	"hardswish":   activation.LoadHardswish,
+	"softmax":     activation.LoadSoftmax,
+	"log_softmax": activation.LoadLogSoftmax,
 }
 
 func RegisterLoadFunc(class string, fn loadFunc) {
 	loadFuncs[class] = fn
 }
 
+type schedulerLoadFunc func(optm optimizer.Optimizer) scheduler.Scheduler
+
+var schedulerLoadFuncs = map[string]schedulerLoadFunc{
+	"step":      scheduler.LoadStepLR,
+	"cosine":    scheduler.LoadCosineAnnealing,
+	"warmup":    scheduler.LoadLinearWarmup,
+	"one_cycle": scheduler.LoadOneCycle,
+	"plateau":   scheduler.LoadReduceOnPlateau,
+}
+
+func RegisterSchedulerLoadFunc(class string, fn schedulerLoadFunc) {
+	schedulerLoadFuncs[class] = fn
+}
+
 type Net struct {
 	layers    []layer.Layer
 	device    consts.DeviceType
 	optimizer optimizer.Optimizer
+	scheduler scheduler.Scheduler
 }
 
 func New(device consts.DeviceType) *Net {
@@ -82,6 +127,15 @@ func (n *Net) GetOptimizer() optimizer.Optimizer {
 	return n.optimizer
 }
 
+// SetScheduler saves the scheduler with the optimizer, it is ignored when no optimizer is set
+func (n *Net) SetScheduler(s scheduler.Scheduler) {
+	n.scheduler = s
+}
+
+func (n *Net) GetScheduler() scheduler.Scheduler {
+	return n.scheduler
+}
+
 func (n *Net) Params() []*tensor.Tensor {
 	var ret []*tensor.Tensor
 	for _, l := range n.layers {
@@ -123,8 +177,10 @@ func (n *Net) WriteTo(w io.Writer) (int64, error) {
 		net.Layers[i] = new(pb.Layer)
 		net.Layers[i].Class = n.layers[i].Class()
 		net.Layers[i].Name = n.layers[i].Name()
+		keys := paramKeys(n.layers[i])
 		for j, p := range n.layers[i].Params() {
 			var param pb.Param
+			param.Name = keys[j]
 			param.Type = uint32(p.ScalarType())
 			param.ElemCount = p.ElemCount()
 			param.Shapes = make([]int64, p.Dims())
@@ -133,6 +189,20 @@ func (n *Net) WriteTo(w io.Writer) (int64, error) {
 			params[param.File] = p
 			net.Layers[i].Params = append(net.Layers[i].Params, &param)
 		}
+		if l, ok := n.layers[i].(layer.BufferLayer); ok {
+			names := l.BufferNames()
+			for j, b := range l.Buffers() {
+				var param pb.Param
+				param.Name = l.Name() + "." + names[j]
+				param.Type = uint32(b.ScalarType())
+				param.ElemCount = b.ElemCount()
+				param.Shapes = make([]int64, b.Dims())
+				copy(param.Shapes, b.Shapes())
+				param.File = fmt.Sprintf("layer_%d_buffer_%d.bin", i, j)
+				params[param.File] = b
+				net.Layers[i].Buffers = append(net.Layers[i].Buffers, &param)
+			}
+		}
 		net.Layers[i].Args = n.layers[i].Args()
 	}
 	if n.optimizer != nil {
@@ -158,6 +228,16 @@ func (n *Net) WriteTo(w io.Writer) (int64, error) {
 			}
 			net.Optimizer.Params = append(net.Optimizer.Params, &op)
 		}
+		if n.scheduler != nil {
+			var buf bytes.Buffer
+			_, err := n.scheduler.WriteTo(&buf)
+			if err != nil {
+				return 0, err
+			}
+			net.Scheduler = new(pb.Scheduler)
+			net.Scheduler.Class = n.scheduler.Class()
+			net.Scheduler.State = buf.Bytes()
+		}
 	}
 	data, err := proto.Marshal(&net)
 	if err != nil {
@@ -221,7 +301,7 @@ func (n *Net) WriteTo(w io.Writer) (int64, error) {
 				bytes = 2
 				return binary.Write(f, binary.BigEndian, param.BFloat16Raw())
 			default:
-				panic(fmt.Errorf("unsupported scalar type: %s", param.ScalarType().String()))
+				return fmt.Errorf("%w: %s", ErrUnsupportedScalarType, param.ScalarType().String())
 			}
 		}()
 		if err != nil {
@@ -246,7 +326,7 @@ func (n *Net) Load(dir string) error {
 	return err
 }
 
-func (n *Net) readSpec(r *zip.Reader) (*pb.Net, error) {
+func readSpec(r *zip.Reader) (*pb.Net, error) {
 	f, err := r.Open("SPEC")
 	if err != nil {
 		return nil, err
@@ -264,10 +344,39 @@ func (n *Net) readSpec(r *zip.Reader) (*pb.Net, error) {
 }
 
 func buildParam[T uint8 | int8 | int16 | uint16 | int32 | int64 |
-	float32 | float64 | bool](r io.Reader, cnt int64, shapes []int64, device consts.DeviceType,
+	float32 | float64 | bool](r io.Reader, file string, fileSize, cnt int64, shapes []int64, device consts.DeviceType,
 	fn func(data []T, opts ...tensor.Option) *tensor.Tensor) (*tensor.Tensor, error) {
+	var zero T
+	elemSize := int64(binary.Size(zero))
+	// the shapes and elem count come from the SPEC, check them before any allocation
+	elems := int64(1)
+	for _, s := range shapes {
+		if s < 0 {
+			return nil, fmt.Errorf("%w: %s: negative dim in shapes %v", ErrCorruptParam, file, shapes)
+		}
+		if s > 0 && elems > math.MaxInt64/elemSize/s {
+			return nil, fmt.Errorf("%w: %s: shapes %v overflow", ErrCorruptParam, file, shapes)
+		}
+		elems *= s
+	}
+	if cnt != elems {
+		return nil, fmt.Errorf("%w: %s: elem count %d does not match shapes %v",
+			ErrCorruptParam, file, cnt, shapes)
+	}
+	size := cnt * elemSize
+	if size != fileSize {
+		return nil, &CorruptParamError{File: file, Expected: size, Actual: fileSize}
+	}
+	buf := make([]byte, size)
+	n, err := io.ReadFull(r, buf)
+	if err != nil {
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			return nil, &CorruptParamError{File: file, Expected: size, Actual: int64(n)}
+		}
+		return nil, err
+	}
 	data := make([]T, cnt)
-	if err := binary.Read(r, binary.BigEndian, data); err != nil {
+	if err := binary.Read(bytes.NewReader(buf), binary.BigEndian, data); err != nil {
 		return nil, err
 	}
 	t := fn(data,
@@ -282,58 +391,108 @@ func (n *Net) loadParam(r *zip.Reader, file string, t consts.ScalarType, cnt int
 		return nil, err
 	}
 	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
 	switch t {
 	case consts.KUint8:
-		return buildParam[uint8](f, cnt, shapes, n.device, tensor.FromUint8)
+		return buildParam[uint8](f, file, size, cnt, shapes, n.device, tensor.FromUint8)
 	case consts.KInt8:
-		return buildParam[int8](f, cnt, shapes, n.device, tensor.FromInt8)
+		return buildParam[int8](f, file, size, cnt, shapes, n.device, tensor.FromInt8)
 	case consts.KInt16:
-		return buildParam[int16](f, cnt, shapes, n.device, tensor.FromInt16)
+		return buildParam[int16](f, file, size, cnt, shapes, n.device, tensor.FromInt16)
 	case consts.KInt32:
-		return buildParam[int32](f, cnt, shapes, n.device, tensor.FromInt32)
+		return buildParam[int32](f, file, size, cnt, shapes, n.device, tensor.FromInt32)
 	case consts.KInt64:
-		return buildParam[int64](f, cnt, shapes, n.device, tensor.FromInt64)
+		return buildParam[int64](f, file, size, cnt, shapes, n.device, tensor.FromInt64)
 	case consts.KHalf:
-		return buildParam[uint16](f, cnt, shapes, n.device, tensor.FromHalfRaw)
+		return buildParam[uint16](f, file, size, cnt, shapes, n.device, tensor.FromHalfRaw)
 	case consts.KFloat:
-		return buildParam[float32](f, cnt, shapes, n.device, tensor.FromFloat32)
+		return buildParam[float32](f, file, size, cnt, shapes, n.device, tensor.FromFloat32)
 	case consts.KDouble:
-		return buildParam[float64](f, cnt, shapes, n.device, tensor.FromFloat64)
+		return buildParam[float64](f, file, size, cnt, shapes, n.device, tensor.FromFloat64)
 	case consts.KBool:
-		return buildParam[bool](f, cnt, shapes, n.device, tensor.FromBool)
+		return buildParam[bool](f, file, size, cnt, shapes, n.device, tensor.FromBool)
 	case consts.KBFloat16:
-		return buildParam[uint16](f, cnt, shapes, n.device, tensor.FromBFloat16Raw)
+		return buildParam[uint16](f, file, size, cnt, shapes, n.device, tensor.FromBFloat16Raw)
 	default:
-		panic(fmt.Errorf("unsupported scalar type: %s", t.String()))
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedScalarType, t.String())
 	}
 }
 
-func (n *Net) ReadFrom(r io.ReaderAt, size int64) (int64, error) {
+// errReader returns err on every read
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func loadLayer(fn loadFunc, class, name string, params, buffers []*tensor.Tensor, args map[string]float32) (l layer.Layer, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %s(%s): %v", ErrInvalidLayer, name, class, r)
+		}
+	}()
+	l = fn(name, params, args)
+	if len(buffers) > 0 {
+		bl, ok := l.(layer.BufferLayer)
+		if !ok || len(buffers) != len(bl.Buffers()) {
+			return nil, fmt.Errorf("%w: %s(%s): unexpected %d buffers", ErrInvalidLayer, name, class, len(buffers))
+		}
+		bl.SetBuffers(buffers)
+	}
+	return l, nil
+}
+
+func openModel(r io.ReaderAt, size int64) (*zip.Reader, *pb.Net, error) {
 	zr, err := zip.NewReader(r, size)
 	if err != nil {
-		return 0, err
+		return nil, nil, err
 	}
 	zr.RegisterDecompressor(zip.Deflate, func(r io.Reader) io.ReadCloser {
 		zr, err := zstd.NewReader(r)
-		runtime.Assert(err)
+		if err != nil {
+			return io.NopCloser(errReader{err})
+		}
 		return io.NopCloser(zr)
 	})
-	spec, err := n.readSpec(zr)
+	spec, err := readSpec(zr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return zr, spec, nil
+}
+
+// ReadSpec reads the definition of the model without loading the params
+func ReadSpec(r io.ReaderAt, size int64) (*pb.Net, error) {
+	_, spec, err := openModel(r, size)
+	return spec, err
+}
+
+func (n *Net) ReadFrom(r io.ReaderAt, size int64) (int64, error) {
+	zr, spec, err := openModel(r, size)
 	if err != nil {
 		return 0, err
 	}
 	layers := spec.GetLayers()
-	n.layers = make([]layer.Layer, len(layers))
-	var wg sync.WaitGroup
-	wg.Add(len(layers))
+	fns := make([]loadFunc, len(layers))
+	for i, l := range layers {
+		fns[i] = loadFuncs[l.GetClass()]
+		if fns[i] == nil {
+			return 0, fmt.Errorf("%w: %s", ErrUnknownLayer, l.GetClass())
+		}
+	}
+	list := make([]layer.Layer, len(layers))
+	var g group
 	for i := 0; i < len(layers); i++ {
+		i := i
 		class := layers[i].GetClass()
-		fn := loadFuncs[class]
-		if fn == nil {
-			panic("unsupported " + class + " layer")
-		}
-		go func(i int) {
-			defer wg.Done()
+		fn := fns[i]
+		g.Go(func() error {
 			var params []*tensor.Tensor
 			for _, param := range layers[i].GetParams() {
 				p, err := n.loadParam(zr,
@@ -341,27 +500,46 @@ func (n *Net) ReadFrom(r io.ReaderAt, size int64) (int64, error) {
 					consts.ScalarType(param.GetType()),
 					param.GetElemCount(),
 					param.GetShapes())
-				runtime.Assert(err)
+				if err != nil {
+					return err
+				}
 				p.SetRequiresGrad(true)
 				params = append(params, p)
 			}
-			n.layers[i] = fn(layers[i].GetName(), params, layers[i].GetArgs())
-		}(i)
+			var buffers []*tensor.Tensor
+			for _, param := range layers[i].GetBuffers() {
+				b, err := n.loadParam(zr,
+					param.GetFile(),
+					consts.ScalarType(param.GetType()),
+					param.GetElemCount(),
+					param.GetShapes())
+				if err != nil {
+					return err
+				}
+				buffers = append(buffers, b)
+			}
+			l, err := loadLayer(fn, class, layers[i].GetName(), params, buffers, layers[i].GetArgs())
+			if err != nil {
+				return err
+			}
+			list[i] = l
+			return nil
+		})
 	}
-	wg.Wait()
+	if err := g.Wait(); err != nil {
+		return 0, err
+	}
+	n.layers = list
 
 	if spec.GetOptimizer() != nil {
-		switch spec.GetOptimizer().GetClass() {
-		case "Adam":
-			n.optimizer = optimizer.NewAdam(n.Params())
-		case "AdamW":
-			n.optimizer = optimizer.NewAdamW(n.Params())
-		default:
-			panic("unsupported optimizer: " + spec.GetOptimizer().GetClass())
+		class := spec.GetOptimizer().GetClass()
+		fn := optimizerLoadFuncs[class]
+		if fn == nil {
+			return 0, fmt.Errorf("%w: %s", ErrUnknownOptimizer, class)
 		}
-		_, err = n.optimizer.GetOptions().ReadFrom(bytes.NewReader(spec.GetOptimizer().GetOptions()))
+		optm, err := fn(n.Params(), spec.GetOptimizer().GetOptions())
 		if err != nil {
-			return 0, err
+			return 0, fmt.Errorf("%w: %s: %v", ErrInvalidOptimizer, class, err)
 		}
 		var state [][]*tensor.Tensor
 		for _, params := range spec.GetOptimizer().GetParams() {
@@ -379,11 +557,59 @@ func (n *Net) ReadFrom(r io.ReaderAt, size int64) (int64, error) {
 			}
 			state = append(state, arr)
 		}
-		n.optimizer.SetState(state)
+		if err := checkOptimizerState(class, n.Params(), state); err != nil {
+			return 0, err
+		}
+		optm.SetState(state)
+		n.optimizer = optm
+	}
+
+	n.scheduler = nil
+	if spec.GetScheduler() != nil && n.optimizer != nil {
+		class := spec.GetScheduler().GetClass()
+		fn := schedulerLoadFuncs[class]
+		if fn == nil {
+			return 0, fmt.Errorf("%w: %s", ErrUnknownScheduler, class)
+		}
+		n.scheduler = fn(n.optimizer)
+		_, err = n.scheduler.ReadFrom(bytes.NewReader(spec.GetScheduler().GetState()))
+		if err != nil {
+			return 0, err
+		}
 	}
 	return size, nil
 }
 
+// checkOptimizerState checks the state of Adam and AdamW read from the model against params,
+// the state of each param is step, exp_avg, exp_avg_sq and max_exp_avg_sq with amsgrad,
+// the state of other optimizers is not checked
+func checkOptimizerState(class string, params []*tensor.Tensor, state [][]*tensor.Tensor) error {
+	if class != "Adam" && class != "AdamW" {
+		return nil
+	}
+	if len(state) != len(params) {
+		return fmt.Errorf("%w: optimizer state of %d params, expected %d",
+			ErrCorruptParam, len(state), len(params))
+	}
+	for i, arr := range state {
+		if len(arr) != 3 && len(arr) != 4 {
+			return fmt.Errorf("%w: optimizer state of param %d has %d tensors, expected 3 or 4",
+				ErrCorruptParam, i, len(arr))
+		}
+		if arr[0].ElemCount() != 1 {
+			return fmt.Errorf("%w: optimizer step of param %d has %d elements",
+				ErrCorruptParam, i, arr[0].ElemCount())
+		}
+		for _, t := range arr[1:] {
+			if !sameShapes(t.Shapes(), params[i].Shapes()) {
+				return fmt.Errorf("%w: optimizer state of param %d has shape %v, expected %v",
+					ErrCorruptParam, i, t.Shapes(), params[i].Shapes())
+			}
+		}
+	}
+	return nil
+}
+
 func (n *Net) Layers() []layer.Layer {
 	return n.layers
 }
@@ -0,0 +1,72 @@
+package net
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/lwch/gotorch/optimizer"
+	"github.com/lwch/gotorch/tensor"
+)
+
+// optimizerLoadFunc creates the optimizer of params from the options written by optimizer.GetOptions,
+// the state is restored by SetState after it returns
+type optimizerLoadFunc func(params []*tensor.Tensor, options []byte) (optimizer.Optimizer, error)
+
+// gotorch only ships Adam and AdamW, other optimizers implementing optimizer.Optimizer
+// can be registered by RegisterOptimizerLoadFunc with their GetName. SGD, RMSprop and
+// Adagrad can not be written in go on top of gotorch for now, it exposes neither the
+// gradients nor any in-place op to update the params held by the layers.
+var optimizerLoadFuncs = map[string]optimizerLoadFunc{
+	"Adam":  loadAdam,
+	"AdamW": loadAdamW,
+}
+
+func RegisterOptimizerLoadFunc(class string, fn optimizerLoadFunc) {
+	optimizerLoadFuncs[class] = fn
+}
+
+// same layout as the options of optimizer.Adam, pinned by TestOptimizerOptionsLayout
+type adamOptions struct {
+	Lr          float64
+	WeightDecay float64
+	Beta1       float64
+	Beta2       float64
+	Eps         float64
+}
+
+func loadAdam(params []*tensor.Tensor, options []byte) (optimizer.Optimizer, error) {
+	var opts adamOptions
+	if err := binary.Read(bytes.NewReader(options), binary.LittleEndian, &opts); err != nil {
+		return nil, err
+	}
+	return optimizer.NewAdam(params,
+		optimizer.WithAdamLr(opts.Lr),
+		optimizer.WithAdamWeightDecay(opts.WeightDecay),
+		optimizer.WithAdamBeta1(opts.Beta1),
+		optimizer.WithAdamBeta2(opts.Beta2),
+		optimizer.WithAdamEps(opts.Eps)), nil
+}
+
+// same layout as the options of optimizer.AdamW, pinned by TestOptimizerOptionsLayout
+type adamWOptions struct {
+	Lr          float64
+	WeightDecay float64
+	Beta1       float64
+	Beta2       float64
+	Eps         float64
+	Amsgrad     bool
+}
+
+func loadAdamW(params []*tensor.Tensor, options []byte) (optimizer.Optimizer, error) {
+	var opts adamWOptions
+	if err := binary.Read(bytes.NewReader(options), binary.LittleEndian, &opts); err != nil {
+		return nil, err
+	}
+	return optimizer.NewAdamW(params,
+		optimizer.WithAdamWLr(opts.Lr),
+		optimizer.WithAdamWWeightDecay(opts.WeightDecay),
+		optimizer.WithAdamWBeta1(opts.Beta1),
+		optimizer.WithAdamWBeta2(opts.Beta2),
+		optimizer.WithAdamWEps(opts.Eps),
+		optimizer.WithAdamWAmsgrad(opts.Amsgrad)), nil
+}
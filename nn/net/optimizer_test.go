@@ -0,0 +1,241 @@
+package net
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/loss"
+	"github.com/lwch/gotorch/optimizer"
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/internal/pb"
+	"github.com/lwch/tnn/nn/layer"
+)
+
+func roundTrip(t *testing.T, n *Net) *Net {
+	var buf bytes.Buffer
+	if _, err := n.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded := New(consts.KCPU)
+	if _, err := loaded.ReadFrom(bytes.NewReader(buf.Bytes()), int64(buf.Len())); err != nil {
+		t.Fatal(err)
+	}
+	return loaded
+}
+
+func optionBytes(t *testing.T, optm optimizer.Optimizer) []byte {
+	var buf bytes.Buffer
+	if _, err := optm.GetOptions().WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestOptimizerRoundTrip(t *testing.T) {
+	builds := map[string]func([]*tensor.Tensor) optimizer.Optimizer{
+		"Adam": func(params []*tensor.Tensor) optimizer.Optimizer {
+			return optimizer.NewAdam(params,
+				optimizer.WithAdamLr(0.01),
+				optimizer.WithAdamBeta1(0.8))
+		},
+		"AdamW": func(params []*tensor.Tensor) optimizer.Optimizer {
+			return optimizer.NewAdamW(params,
+				optimizer.WithAdamWLr(0.01),
+				optimizer.WithAdamWWeightDecay(0.1),
+				optimizer.WithAdamWAmsgrad(true))
+		},
+	}
+	for class, build := range builds {
+		n := New(consts.KCPU)
+		l := layer.NewLinear("linear", 2, 1)
+		n.Add(l)
+		optm := build(n.Params())
+		x := tensor.FromFloat32([]float32{1, 2}, tensor.WithShapes(1, 2))
+		y := tensor.FromFloat32([]float32{1}, tensor.WithShapes(1, 1))
+		loss.NewMse(l.Forward(x), y).Backward()
+		optm.Step()
+		n.SetOptimizer(optm)
+
+		loaded := roundTrip(t, n).GetOptimizer()
+		if loaded.GetName() != class {
+			t.Fatalf("unexpected optimizer %s, expected %s", loaded.GetName(), class)
+		}
+		if loaded.GetLr() != optm.GetLr() {
+			t.Fatalf("%s: unexpected lr %f, expected %f", class, loaded.GetLr(), optm.GetLr())
+		}
+		if !bytes.Equal(optionBytes(t, loaded), optionBytes(t, optm)) {
+			t.Fatalf("%s: options mismatch", class)
+		}
+		state, loadedState := optm.GetState(), loaded.GetState()
+		if len(state) != len(loadedState) {
+			t.Fatalf("%s: unexpected state size %d, expected %d", class, len(loadedState), len(state))
+		}
+		for i := range state {
+			for j := range state[i] {
+				a := state[i][j].ToScalarType(consts.KDouble).Float64Value()
+				b := loadedState[i][j].ToScalarType(consts.KDouble).Float64Value()
+				for k := range a {
+					if a[k] != b[k] {
+						t.Fatalf("%s: state %d.%d mismatch", class, i, j)
+					}
+				}
+			}
+		}
+	}
+}
+
+type testOptions struct {
+	Lr float64
+}
+
+func (opts *testOptions) WriteTo(w io.Writer) (int64, error) {
+	return 8, binary.Write(w, binary.LittleEndian, opts)
+}
+
+func (opts *testOptions) ReadFrom(r io.Reader) (int64, error) {
+	return 8, binary.Read(r, binary.LittleEndian, opts)
+}
+
+// testOptimizer does nothing, it is only used to test the registry
+type testOptimizer struct {
+	options testOptions
+}
+
+func (optm *testOptimizer) GetName() string               { return "test" }
+func (optm *testOptimizer) Step()                         {}
+func (optm *testOptimizer) GetLr() float64                { return optm.options.Lr }
+func (optm *testOptimizer) SetLr(lr float64)              { optm.options.Lr = lr }
+func (optm *testOptimizer) GetState() [][]*tensor.Tensor  { return nil }
+func (optm *testOptimizer) SetState([][]*tensor.Tensor)   {}
+func (optm *testOptimizer) GetOptions() optimizer.Options { return &optm.options }
+
+func TestRegisterOptimizer(t *testing.T) {
+	n := New(consts.KCPU)
+	n.Add(layer.NewLinear("linear", 2, 1))
+	n.SetOptimizer(&testOptimizer{options: testOptions{Lr: 0.5}})
+
+	var buf bytes.Buffer
+	if _, err := n.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	_, err := New(consts.KCPU).ReadFrom(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if !errors.Is(err, ErrUnknownOptimizer) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	RegisterOptimizerLoadFunc("test", func(_ []*tensor.Tensor, options []byte) (optimizer.Optimizer, error) {
+		var optm testOptimizer
+		_, err := optm.options.ReadFrom(bytes.NewReader(options))
+		return &optm, err
+	})
+	defer delete(optimizerLoadFuncs, "test")
+	if lr := roundTrip(t, n).GetOptimizer().GetLr(); lr != 0.5 {
+		t.Fatalf("unexpected lr %f", lr)
+	}
+}
+
+// TestOptimizerOptionsLayout pins the layout of adamOptions and adamWOptions to the private
+// options of gotorch, every field has a distinct value so a reordered field is detected
+func TestOptimizerOptionsLayout(t *testing.T) {
+	w := tensor.Zeros(consts.KFloat, tensor.WithShapes(2))
+	w.SetRequiresGrad(true)
+	params := []*tensor.Tensor{w}
+
+	data := optionBytes(t, optimizer.NewAdam(params,
+		optimizer.WithAdamLr(0.1),
+		optimizer.WithAdamWeightDecay(0.2),
+		optimizer.WithAdamBeta1(0.3),
+		optimizer.WithAdamBeta2(0.4),
+		optimizer.WithAdamEps(0.5)))
+	if len(data) != binary.Size(adamOptions{}) {
+		t.Fatalf("unexpected adam options size %d, expected %d", len(data), binary.Size(adamOptions{}))
+	}
+	var adam adamOptions
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &adam); err != nil {
+		t.Fatal(err)
+	}
+	if adam != (adamOptions{Lr: 0.1, WeightDecay: 0.2, Beta1: 0.3, Beta2: 0.4, Eps: 0.5}) {
+		t.Fatalf("unexpected adam options %+v", adam)
+	}
+
+	data = optionBytes(t, optimizer.NewAdamW(params,
+		optimizer.WithAdamWLr(0.1),
+		optimizer.WithAdamWWeightDecay(0.2),
+		optimizer.WithAdamWBeta1(0.3),
+		optimizer.WithAdamWBeta2(0.4),
+		optimizer.WithAdamWEps(0.5),
+		optimizer.WithAdamWAmsgrad(true)))
+	if len(data) != binary.Size(adamWOptions{}) {
+		t.Fatalf("unexpected adamw options size %d, expected %d", len(data), binary.Size(adamWOptions{}))
+	}
+	var adamW adamWOptions
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &adamW); err != nil {
+		t.Fatal(err)
+	}
+	if adamW != (adamWOptions{Lr: 0.1, WeightDecay: 0.2, Beta1: 0.3, Beta2: 0.4, Eps: 0.5, Amsgrad: true}) {
+		t.Fatalf("unexpected adamw options %+v", adamW)
+	}
+}
+
+func TestReadCorruptOptimizerState(t *testing.T) {
+	n := New(consts.KCPU)
+	l := layer.NewLinear("linear", 2, 1, layer.WithBias(false))
+	n.Add(l)
+	optm := optimizer.NewAdam(n.Params())
+	x := tensor.FromFloat32([]float32{1, 2}, tensor.WithShapes(1, 2))
+	y := tensor.FromFloat32([]float32{1}, tensor.WithShapes(1, 1))
+	loss.NewMse(l.Forward(x), y).Backward()
+	optm.Step()
+	n.SetOptimizer(optm)
+	var buf bytes.Buffer
+	if _, err := n.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read := func(modify func(*pb.Optimizer)) error {
+		zr, spec, err := openModel(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files := make(map[string][]byte)
+		for _, file := range zr.File {
+			if file.Name == "SPEC" {
+				continue
+			}
+			f, err := file.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			files[file.Name], err = io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		modify(spec.GetOptimizer())
+		r := buildModel(t, spec, files)
+		_, err = New(consts.KCPU).ReadFrom(r, r.Size())
+		return err
+	}
+	if err := read(func(*pb.Optimizer) {}); err != nil {
+		t.Fatal(err)
+	}
+	for name, modify := range map[string]func(*pb.Optimizer){
+		"missing param": func(optm *pb.Optimizer) {
+			optm.Params = nil
+		},
+		"missing tensor": func(optm *pb.Optimizer) {
+			optm.Params[0].Params = optm.Params[0].Params[:2]
+		},
+		"shape mismatch": func(optm *pb.Optimizer) {
+			optm.Params[0].Params[1].Shapes = []int64{2, 1} // the weight is (1, 2)
+		},
+	} {
+		if err := read(modify); !errors.Is(err, ErrCorruptParam) {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package net
+
+import "github.com/lwch/tnn/nn/layer"
+
+// Scope adds layers into net with a dotted name prefix, e.g. blocks.3.attn
+type Scope struct {
+	net    *Net
+	prefix string
+}
+
+func (n *Net) Scope(name string) *Scope {
+	return &Scope{net: n, prefix: name}
+}
+
+// Scope creates a nested scope
+func (s *Scope) Scope(name string) *Scope {
+	return &Scope{net: s.net, prefix: s.prefix + "." + name}
+}
+
+func (s *Scope) Prefix() string {
+	return s.prefix
+}
+
+// Add renames each layer to prefix.name and adds it into net
+func (s *Scope) Add(layers ...layer.Layer) {
+	for _, l := range layers {
+		l.SetName(s.prefix + "." + l.Name())
+	}
+	s.net.Add(layers...)
+}
@@ -0,0 +1,34 @@
+package net
+
+import (
+	"fmt"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/nn/layer"
+)
+
+// Sequential run each layer one by one, the output of one layer is the input of next layer
+type Sequential struct {
+	Net
+}
+
+func NewSequential(device consts.DeviceType, modules ...layer.Module) *Sequential {
+	var seq Sequential
+	seq.device = device
+	for _, m := range modules {
+		seq.Add(m)
+	}
+	return &seq
+}
+
+func (seq *Sequential) Forward(x *tensor.Tensor, train bool) *tensor.Tensor {
+	for _, l := range seq.layers {
+		m, ok := l.(layer.Module)
+		if !ok {
+			panic(fmt.Errorf("layer %s(%s) is not a module", l.Name(), l.Class()))
+		}
+		x = m.Call(x, train)
+	}
+	return x
+}
@@ -0,0 +1,88 @@
+package net
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/nn/layer"
+	"github.com/lwch/tnn/nn/layer/activation"
+)
+
+func TestSequential(t *testing.T) {
+	seq := NewSequential(consts.KCPU,
+		layer.NewLinear("hidden", 2, 4),
+		activation.NewReLU(),
+		layer.NewLinear("output", 4, 1))
+	x := tensor.FromFloat32([]float32{0, 0, 0, 1, 1, 0, 1, 1}, tensor.WithShapes(4, 2))
+	y := seq.Forward(x, false).Float32Value()
+
+	var buf bytes.Buffer
+	if _, err := seq.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewSequential(consts.KCPU)
+	if _, err := loaded.ReadFrom(bytes.NewReader(buf.Bytes()), int64(buf.Len())); err != nil {
+		t.Fatal(err)
+	}
+	y2 := loaded.Forward(x, false).Float32Value()
+	for i := range y {
+		if y[i] != y2[i] {
+			t.Fatalf("unexpected output %d: %f != %f", i, y[i], y2[i])
+		}
+	}
+}
+
+var _ = []layer.Module{
+	&layer.Linear{},
+	&layer.Dropout{},
+	&layer.Conv1D{},
+	&layer.Conv2D{},
+	&layer.Conv3D{},
+	&layer.SeparableConv2D{},
+	&layer.MaxPool1D{},
+	&layer.MaxPool2D{},
+	&layer.AvgPool1D{},
+	&layer.AvgPool2D{},
+	&layer.AdaptiveAvgPool1D{},
+	&layer.AdaptiveMaxPool1D{},
+	&layer.AdaptiveAvgPool2D{},
+	&layer.AdaptiveMaxPool2D{},
+	&layer.GlobalAvgPool{},
+	&layer.GlobalMaxPool{},
+	&layer.ConvTranspose1D{},
+	&layer.ConvTranspose2D{},
+	&layer.ConvTranspose3D{},
+	&layer.Rnn{},
+	&layer.Lstm{},
+	&layer.Gru{},
+	&layer.Attention{},
+	&layer.Attention1{},
+	&layer.LayerNorm{},
+	&layer.RMSNorm{},
+	&layer.BatchNorm1D{},
+	&layer.BatchNorm2D{},
+	&layer.GroupNorm{},
+	&layer.InstanceNorm{},
+	&layer.Flatten{},
+	&layer.Embedding{},
+	&layer.ReZero{},
+	&layer.PReLU{},
+	&layer.FeedForward{},
+	&layer.SinusoidalEncoding{},
+	&layer.PositionEmbedding{},
+	&activation.Sigmoid{},
+	&activation.Tanh{},
+	&activation.ReLU{},
+	&activation.GeLU{},
+	&activation.LeakyReLU{},
+	&activation.ELU{},
+	&activation.SELU{},
+	&activation.SiLU{},
+	&activation.Mish{},
+	&activation.Softplus{},
+	&activation.Hardswish{},
+	&activation.Softmax{},
+	&activation.LogSoftmax{},
+}
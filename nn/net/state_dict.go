@@ -0,0 +1,240 @@
+package net
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/nn/layer"
+)
+
+var ErrStateDictMismatch = errors.New("state dict mismatch")
+
+// LoadStateDictResult reports the keys which are not matched
+type LoadStateDictResult struct {
+	Missing    []string // keys of the net which are not in the state dict
+	Unexpected []string // keys of the state dict which are not in the net
+}
+
+// paramKeys returns the dotted path of each param in l.Params() order,
+// params without name are addressed by their index
+func paramKeys(l layer.Layer) []string {
+	params := l.Params()
+	names := l.ParamNames()
+	ret := make([]string, len(params))
+	for i := range params {
+		name := strconv.Itoa(i)
+		if i < len(names) {
+			name = names[i]
+		}
+		ret[i] = l.Name() + "." + name
+	}
+	return ret
+}
+
+// layerBuffers returns the buffers of l and their dotted path, e.g. bn.running_mean
+func layerBuffers(l layer.Layer) ([]string, []*tensor.Tensor) {
+	bl, ok := l.(layer.BufferLayer)
+	if !ok {
+		return nil, nil
+	}
+	names := bl.BufferNames()
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = l.Name() + "." + name
+	}
+	return keys, bl.Buffers()
+}
+
+// StateDict returns all params and buffers keyed by their dotted path, e.g. blocks.3.attn.q
+func (n *Net) StateDict() map[string]*tensor.Tensor {
+	ret := make(map[string]*tensor.Tensor)
+	for _, l := range n.layers {
+		keys := paramKeys(l)
+		for i, p := range l.Params() {
+			ret[keys[i]] = p
+		}
+		keys, buffers := layerBuffers(l)
+		for i, b := range buffers {
+			ret[keys[i]] = b
+		}
+	}
+	return ret
+}
+
+func sameShapes(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadStateDict replaces the params and buffers of each layer by the tensors in dict with the same key,
+// every key is checked before any layer is changed, when strict is true any missing or unexpected key
+// is an error and nothing is loaded.
+//
+// The tensor objects of the layers are swapped rather than written in place, so an optimizer built
+// from Params() before loading keeps updating the old tensors and must be rebuilt after loading.
+func (n *Net) LoadStateDict(dict map[string]*tensor.Tensor, strict bool) (*LoadStateDictResult, error) {
+	var ret LoadStateDictResult
+	used := make(map[string]bool, len(dict))
+	for _, l := range n.layers {
+		keys, buffers := layerBuffers(l)
+		keys = append(paramKeys(l), keys...)
+		tensors := append(l.Params(), buffers...)
+		for i, key := range keys {
+			p, ok := dict[key]
+			if !ok {
+				ret.Missing = append(ret.Missing, key)
+				continue
+			}
+			used[key] = true
+			if !sameShapes(p.Shapes(), tensors[i].Shapes()) {
+				return &ret, fmt.Errorf("%w: shape of %s is %v, expected %v",
+					ErrStateDictMismatch, key, p.Shapes(), tensors[i].Shapes())
+			}
+		}
+	}
+	for key := range dict {
+		if !used[key] {
+			ret.Unexpected = append(ret.Unexpected, key)
+		}
+	}
+	sort.Strings(ret.Unexpected)
+	if strict && (len(ret.Missing) > 0 || len(ret.Unexpected) > 0) {
+		return &ret, fmt.Errorf("%w: missing keys %v, unexpected keys %v",
+			ErrStateDictMismatch, ret.Missing, ret.Unexpected)
+	}
+	// only the tensors are replaced, so the runtime state of the layers like caches is kept
+	for _, l := range n.layers {
+		params := l.Params()
+		var changed bool
+		for i, key := range paramKeys(l) {
+			p, ok := dict[key]
+			if !ok {
+				continue
+			}
+			p = p.ToDevice(n.device)
+			p.SetRequiresGrad(true)
+			params[i] = p
+			changed = true
+		}
+		if changed {
+			l.SetParams(params)
+		}
+		keys, buffers := layerBuffers(l)
+		changed = false
+		for i, key := range keys {
+			b, ok := dict[key]
+			if !ok {
+				continue
+			}
+			buffers[i] = b.ToDevice(n.device)
+			changed = true
+		}
+		if changed {
+			l.(layer.BufferLayer).SetBuffers(buffers)
+		}
+	}
+	return &ret, nil
+}
+
+// RemapStateDict returns a copy of dict with every key renamed by fn, keys renamed to empty are dropped,
+// it is used to load checkpoints saved with other layer names by LoadStateDict
+func RemapStateDict(dict map[string]*tensor.Tensor, fn func(key string) string) map[string]*tensor.Tensor {
+	ret := make(map[string]*tensor.Tensor, len(dict))
+	for key, t := range dict {
+		if key = fn(key); len(key) > 0 {
+			ret[key] = t
+		}
+	}
+	return ret
+}
+
+// ReadStateDict reads params and buffers from a model written by WriteTo keyed by their dotted path
+func (n *Net) ReadStateDict(r io.ReaderAt, size int64) (map[string]*tensor.Tensor, error) {
+	zr, spec, err := openModel(r, size)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]*tensor.Tensor)
+	for _, l := range spec.GetLayers() {
+		var params []*tensor.Tensor
+		named := true
+		for _, param := range l.GetParams() {
+			p, err := n.loadParam(zr,
+				param.GetFile(),
+				consts.ScalarType(param.GetType()),
+				param.GetElemCount(),
+				param.GetShapes())
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, p)
+			named = named && len(param.GetName()) > 0
+		}
+		for _, param := range l.GetBuffers() {
+			b, err := n.loadParam(zr,
+				param.GetFile(),
+				consts.ScalarType(param.GetType()),
+				param.GetElemCount(),
+				param.GetShapes())
+			if err != nil {
+				return nil, err
+			}
+			ret[param.GetName()] = b
+		}
+		if len(params) == 0 {
+			continue
+		}
+		var keys []string
+		if named {
+			for _, param := range l.GetParams() {
+				keys = append(keys, param.GetName())
+			}
+		} else {
+			// checkpoints written before params were named
+			fn := loadFuncs[l.GetClass()]
+			if fn == nil {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownLayer, l.GetClass())
+			}
+			nl, err := loadLayer(fn, l.GetClass(), l.GetName(), params, nil, l.GetArgs())
+			if err != nil {
+				return nil, err
+			}
+			keys = paramKeys(nl)
+			if len(keys) != len(params) {
+				return nil, fmt.Errorf("%w: %s(%s) has %d params, got %d", ErrInvalidLayer,
+					l.GetName(), l.GetClass(), len(keys), len(params))
+			}
+		}
+		for i, p := range params {
+			ret[keys[i]] = p
+		}
+	}
+	return ret, nil
+}
+
+// ReadStateDictFile reads params from the model file keyed by their dotted path
+func (n *Net) ReadStateDictFile(dir string) (map[string]*tensor.Tensor, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return n.ReadStateDict(f, fi.Size())
+}
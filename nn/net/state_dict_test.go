@@ -0,0 +1,137 @@
+package net
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/tnn/nn/layer"
+)
+
+func buildBlocks() *Net {
+	net := New(consts.KCPU)
+	blocks := net.Scope("blocks")
+	for _, i := range []string{"0", "1"} {
+		blocks.Scope(i).Add(
+			layer.NewAttention("attn", 4, 1, 0, false),
+			layer.NewLinear("output", 4, 4))
+	}
+	return net
+}
+
+func TestStateDict(t *testing.T) {
+	net := buildBlocks()
+	dict := net.StateDict()
+	for _, key := range []string{"blocks.0.attn.q", "blocks.1.attn.v", "blocks.1.output.w"} {
+		if dict[key] == nil {
+			t.Fatalf("missing key %s", key)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := net.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := New(consts.KCPU).ReadStateDict(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := buildBlocks()
+	if _, err = target.LoadStateDict(loaded, true); err != nil {
+		t.Fatal(err)
+	}
+	want := dict["blocks.1.output.w"].Float32Value()
+	got := target.StateDict()["blocks.1.output.w"].Float32Value()
+	for i := range want {
+		if want[i] != got[i] {
+			t.Fatalf("unexpected value %d: %f != %f", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLoadStateDictStrict(t *testing.T) {
+	net := buildBlocks()
+	dict := net.StateDict()
+	delete(dict, "blocks.0.attn.k")
+	dict["blocks.2.attn.q"] = dict["blocks.0.attn.q"]
+	ret, err := buildBlocks().LoadStateDict(dict, true)
+	if !errors.Is(err, ErrStateDictMismatch) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ret.Missing) != 1 || ret.Missing[0] != "blocks.0.attn.k" {
+		t.Fatalf("unexpected missing keys: %v", ret.Missing)
+	}
+	if len(ret.Unexpected) != 1 || ret.Unexpected[0] != "blocks.2.attn.q" {
+		t.Fatalf("unexpected unexpected keys: %v", ret.Unexpected)
+	}
+	if _, err = buildBlocks().LoadStateDict(dict, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadStateDictInPlace(t *testing.T) {
+	dict := buildBlocks().StateDict()
+	target := buildBlocks()
+	attn := target.Layers()[0].(*layer.Attention)
+	attn.SetWindow(2)
+	if _, err := target.LoadStateDict(dict, true); err != nil {
+		t.Fatal(err)
+	}
+	if target.Layers()[0] != attn {
+		t.Fatal("layer should be loaded in place")
+	}
+	if attn.Args()["window"] != 2 {
+		t.Fatal("settings of the layer should be kept")
+	}
+	want := dict["blocks.0.attn.q"].Float32Value()
+	got := attn.Params()[0].Float32Value()
+	for i := range want {
+		if want[i] != got[i] {
+			t.Fatalf("unexpected value %d: %f != %f", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLoadStateDictShapeMismatch(t *testing.T) {
+	dict := buildBlocks().StateDict()
+	dict["blocks.1.output.w"] = layer.NewLinear("output", 4, 3).Params()[0]
+	target := buildBlocks()
+	before := target.StateDict()["blocks.0.attn.q"].Float32Value()
+	if _, err := target.LoadStateDict(dict, false); !errors.Is(err, ErrStateDictMismatch) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// nothing is loaded when any key is invalid
+	after := target.StateDict()["blocks.0.attn.q"].Float32Value()
+	for i := range before {
+		if before[i] != after[i] {
+			t.Fatalf("param %d changed: %f != %f", i, after[i], before[i])
+		}
+	}
+}
+
+func TestRemapStateDict(t *testing.T) {
+	dict := buildBlocks().StateDict()
+	old := RemapStateDict(dict, func(key string) string {
+		return strings.Replace(key, "blocks.", "layers.", 1)
+	})
+	if old["layers.0.attn.q"] != dict["blocks.0.attn.q"] {
+		t.Fatal("key not renamed")
+	}
+	dropped := RemapStateDict(dict, func(key string) string {
+		if strings.HasSuffix(key, ".v") {
+			return ""
+		}
+		return key
+	})
+	if _, ok := dropped["blocks.0.attn.v"]; ok || len(dropped) != len(dict)-2 {
+		t.Fatalf("unexpected keys: %d", len(dropped))
+	}
+	back := RemapStateDict(old, func(key string) string {
+		return strings.Replace(key, "layers.", "blocks.", 1)
+	})
+	if _, err := buildBlocks().LoadStateDict(back, true); err != nil {
+		t.Fatal(err)
+	}
+}
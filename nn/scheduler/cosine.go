@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/lwch/gotorch/optimizer"
+)
+
+type cosineState struct {
+	BaseLr float64
+	TMax   int64
+	EtaMin float64
+	Steps  int64
+}
+
+func (s cosineState) validate() error {
+	if s.TMax <= 0 || s.Steps < 0 {
+		return fmt.Errorf("%w: t_max=%d, steps=%d", ErrInvalidState, s.TMax, s.Steps)
+	}
+	return nil
+}
+
+// CosineAnnealing anneals the learning rate from the initial value to etaMin in tMax steps
+// following a half cosine wave, it stays at etaMin after tMax steps
+type CosineAnnealing struct {
+	base
+	state cosineState
+}
+
+var _ Scheduler = &CosineAnnealing{}
+
+func NewCosineAnnealing(optm optimizer.Optimizer, tMax int, etaMin float64) *CosineAnnealing {
+	var s CosineAnnealing
+	s.optm = optm
+	s.class = "cosine"
+	s.state.BaseLr = optm.GetLr()
+	s.state.TMax = int64(tMax)
+	s.state.EtaMin = etaMin
+	if err := s.state.validate(); err != nil {
+		panic(err)
+	}
+	return &s
+}
+
+func LoadCosineAnnealing(optm optimizer.Optimizer) Scheduler {
+	return NewCosineAnnealing(optm, 1, 0)
+}
+
+func cosine(from, to float64, pct float64) float64 {
+	return to + (from-to)*(1+math.Cos(math.Pi*pct))/2
+}
+
+func (s *CosineAnnealing) lr() float64 {
+	if s.state.Steps >= s.state.TMax {
+		return s.state.EtaMin
+	}
+	return cosine(s.state.BaseLr, s.state.EtaMin, float64(s.state.Steps)/float64(s.state.TMax))
+}
+
+func (s *CosineAnnealing) Step() {
+	s.state.Steps++
+	s.optm.SetLr(s.lr())
+}
+
+func (s *CosineAnnealing) WriteTo(w io.Writer) (int64, error) {
+	return writeState(w, &s.state)
+}
+
+func (s *CosineAnnealing) ReadFrom(r io.Reader) (int64, error) {
+	n, err := readState(r, &s.state)
+	if err != nil {
+		return n, err
+	}
+	if err := s.state.validate(); err != nil {
+		return n, err
+	}
+	s.optm.SetLr(s.lr())
+	return n, nil
+}
@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/lwch/gotorch/optimizer"
+)
+
+type oneCycleState struct {
+	MaxLr          float64
+	Total          int64
+	PctStart       float64
+	DivFactor      float64
+	FinalDivFactor float64
+	Steps          int64
+}
+
+func (s oneCycleState) validate() error {
+	if s.Total <= 0 || s.Steps < 0 || s.PctStart < 0 || s.PctStart > 1 ||
+		s.DivFactor <= 0 || s.FinalDivFactor <= 0 {
+		return fmt.Errorf("%w: total=%d, steps=%d, pct_start=%f, div_factor=%f, final_div_factor=%f",
+			ErrInvalidState, s.Total, s.Steps, s.PctStart, s.DivFactor, s.FinalDivFactor)
+	}
+	return nil
+}
+
+// OneCycle anneals the learning rate from maxLr/divFactor to maxLr in the first
+// pctStart of total steps, then anneals it to maxLr/divFactor/finalDivFactor
+type OneCycle struct {
+	base
+	state oneCycleState
+}
+
+var _ Scheduler = &OneCycle{}
+
+// NewOneCycle creates one-cycle scheduler, pctStart is 0.3, divFactor is 25 and finalDivFactor is 1e4 in PyTorch
+func NewOneCycle(optm optimizer.Optimizer, maxLr float64, total int, pctStart, divFactor, finalDivFactor float64) *OneCycle {
+	var s OneCycle
+	s.optm = optm
+	s.class = "one_cycle"
+	s.state.MaxLr = maxLr
+	s.state.Total = int64(total)
+	s.state.PctStart = pctStart
+	s.state.DivFactor = divFactor
+	s.state.FinalDivFactor = finalDivFactor
+	if err := s.state.validate(); err != nil {
+		panic(err)
+	}
+	s.optm.SetLr(s.lr())
+	return &s
+}
+
+func LoadOneCycle(optm optimizer.Optimizer) Scheduler {
+	var s OneCycle
+	s.optm = optm
+	s.class = "one_cycle"
+	return &s
+}
+
+func (s *OneCycle) lr() float64 {
+	initial := s.state.MaxLr / s.state.DivFactor
+	final := initial / s.state.FinalDivFactor
+	// the warmup phase takes at least one step, so step/up is never 0/0
+	// when pctStart*total is not greater than 1
+	up := math.Max(s.state.PctStart*float64(s.state.Total)-1, 1)
+	down := float64(s.state.Total) - 1
+	step := float64(s.state.Steps)
+	switch {
+	case step <= up:
+		return cosine(initial, s.state.MaxLr, step/up)
+	case step < down:
+		return cosine(s.state.MaxLr, final, (step-up)/(down-up))
+	default:
+		return final
+	}
+}
+
+func (s *OneCycle) Step() {
+	s.state.Steps++
+	s.optm.SetLr(s.lr())
+}
+
+func (s *OneCycle) WriteTo(w io.Writer) (int64, error) {
+	return writeState(w, &s.state)
+}
+
+func (s *OneCycle) ReadFrom(r io.Reader) (int64, error) {
+	n, err := readState(r, &s.state)
+	if err != nil {
+		return n, err
+	}
+	if err := s.state.validate(); err != nil {
+		return n, err
+	}
+	s.optm.SetLr(s.lr())
+	return n, nil
+}
@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/lwch/gotorch/optimizer"
+)
+
+type plateauState struct {
+	Factor    float64
+	Patience  int64
+	Threshold float64
+	MinLr     float64
+	Lr        float64
+	Best      float64
+	Bad       int64
+}
+
+func (s plateauState) validate() error {
+	if s.Factor <= 0 || s.Factor >= 1 || s.Patience < 0 || s.Bad < 0 {
+		return fmt.Errorf("%w: factor=%f, patience=%d, bad=%d", ErrInvalidState, s.Factor, s.Patience, s.Bad)
+	}
+	return nil
+}
+
+// ReduceOnPlateau multiplies the learning rate by factor when the observed metric
+// has not decreased by more than threshold (relative) for patience observations
+type ReduceOnPlateau struct {
+	base
+	state plateauState
+}
+
+var _ Scheduler = &ReduceOnPlateau{}
+
+// NewReduceOnPlateau creates reduce-on-plateau scheduler, factor is 0.1, patience is 10 and threshold is 1e-4 in PyTorch
+func NewReduceOnPlateau(optm optimizer.Optimizer, factor float64, patience int, threshold, minLr float64) *ReduceOnPlateau {
+	var s ReduceOnPlateau
+	s.optm = optm
+	s.class = "plateau"
+	s.state.Factor = factor
+	s.state.Patience = int64(patience)
+	s.state.Threshold = threshold
+	s.state.MinLr = minLr
+	s.state.Lr = optm.GetLr()
+	s.state.Best = math.Inf(1)
+	if err := s.state.validate(); err != nil {
+		panic(err)
+	}
+	return &s
+}
+
+func LoadReduceOnPlateau(optm optimizer.Optimizer) Scheduler {
+	return NewReduceOnPlateau(optm, 0.1, 10, 1e-4, 0)
+}
+
+// Step does nothing, the learning rate is only changed by Observe
+func (s *ReduceOnPlateau) Step() {
+}
+
+// Observe reports the metric to minimize, e.g. validation loss
+func (s *ReduceOnPlateau) Observe(metric float64) {
+	if metric < s.state.Best*(1-s.state.Threshold) {
+		s.state.Best = metric
+		s.state.Bad = 0
+		return
+	}
+	s.state.Bad++
+	if s.state.Bad <= s.state.Patience {
+		return
+	}
+	s.state.Lr = math.Max(s.state.Lr*s.state.Factor, s.state.MinLr)
+	s.state.Bad = 0
+	s.optm.SetLr(s.state.Lr)
+}
+
+func (s *ReduceOnPlateau) WriteTo(w io.Writer) (int64, error) {
+	return writeState(w, &s.state)
+}
+
+func (s *ReduceOnPlateau) ReadFrom(r io.Reader) (int64, error) {
+	n, err := readState(r, &s.state)
+	if err != nil {
+		return n, err
+	}
+	if err := s.state.validate(); err != nil {
+		return n, err
+	}
+	s.optm.SetLr(s.state.Lr)
+	return n, nil
+}
@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/lwch/gotorch/optimizer"
+)
+
+// ErrInvalidState is returned by ReadFrom when the saved options are out of range
+var ErrInvalidState = errors.New("invalid scheduler state")
+
+// Scheduler drives the learning rate of the optimizer,
+// WriteTo and ReadFrom persist both the options and the progress
+type Scheduler interface {
+	Class() string
+	// Step advances one step and updates the learning rate of the optimizer
+	Step()
+	GetLr() float64
+	io.WriterTo
+	io.ReaderFrom
+}
+
+type base struct {
+	optm  optimizer.Optimizer
+	class string
+}
+
+func (b *base) Class() string {
+	return b.class
+}
+
+func (b *base) GetLr() float64 {
+	return b.optm.GetLr()
+}
+
+func writeState(w io.Writer, state any) (int64, error) {
+	return int64(binary.Size(state)), binary.Write(w, binary.LittleEndian, state)
+}
+
+func readState(r io.Reader, state any) (int64, error) {
+	return int64(binary.Size(state)), binary.Read(r, binary.LittleEndian, state)
+}
@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/optimizer"
+	"github.com/lwch/gotorch/tensor"
+)
+
+func newOptimizer() optimizer.Optimizer {
+	w := tensor.Zeros(consts.KFloat, tensor.WithShapes(2, 2))
+	w.SetRequiresGrad(true)
+	return optimizer.NewAdam([]*tensor.Tensor{w}, optimizer.WithAdamLr(0.1))
+}
+
+func TestResume(t *testing.T) {
+	build := map[string]func(optimizer.Optimizer) Scheduler{
+		"step": func(optm optimizer.Optimizer) Scheduler {
+			return NewStepLR(optm, 3, 0.5)
+		},
+		"cosine": func(optm optimizer.Optimizer) Scheduler {
+			return NewCosineAnnealing(optm, 10, 0.001)
+		},
+		"warmup": func(optm optimizer.Optimizer) Scheduler {
+			return NewLinearWarmup(optm, 5, 0.1)
+		},
+		"one_cycle": func(optm optimizer.Optimizer) Scheduler {
+			return NewOneCycle(optm, 0.1, 10, 0.3, 25, 1e4)
+		},
+		"plateau": func(optm optimizer.Optimizer) Scheduler {
+			return NewReduceOnPlateau(optm, 0.5, 1, 0, 0.001)
+		},
+	}
+	load := map[string]func(optimizer.Optimizer) Scheduler{
+		"step":      LoadStepLR,
+		"cosine":    LoadCosineAnnealing,
+		"warmup":    LoadLinearWarmup,
+		"one_cycle": LoadOneCycle,
+		"plateau":   LoadReduceOnPlateau,
+	}
+	// plateau only changes the learning rate on Observe
+	advance := func(s Scheduler) {
+		s.Step()
+		if p, ok := s.(*ReduceOnPlateau); ok {
+			p.Observe(1)
+		}
+	}
+	for class, fn := range build {
+		s := fn(newOptimizer())
+		if s.Class() != class {
+			t.Fatalf("unexpected class %s, expected %s", s.Class(), class)
+		}
+		for i := 0; i < 4; i++ {
+			advance(s)
+		}
+		var buf bytes.Buffer
+		if _, err := s.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		resumed := load[class](newOptimizer())
+		if _, err := resumed.ReadFrom(&buf); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 8; i++ {
+			if math.Abs(s.GetLr()-resumed.GetLr()) > 1e-12 {
+				t.Fatalf("%s: step %d lr %f != %f", class, i, resumed.GetLr(), s.GetLr())
+			}
+			advance(s)
+			advance(resumed)
+		}
+	}
+}
+
+func TestReduceOnPlateau(t *testing.T) {
+	s := NewReduceOnPlateau(newOptimizer(), 0.5, 1, 0, 0.03)
+	for _, loss := range []float64{1, 0.9, 0.9, 0.9, 0.9, 0.9, 0.9} {
+		s.Observe(loss)
+	}
+	if math.Abs(s.GetLr()-0.03) > 1e-12 {
+		t.Fatalf("unexpected lr %f", s.GetLr())
+	}
+}
+
+func TestStepUsesLr(t *testing.T) {
+	w := tensor.Zeros(consts.KFloat, tensor.WithShapes(2, 2))
+	w.SetRequiresGrad(true)
+	optm := optimizer.NewAdam([]*tensor.Tensor{w}, optimizer.WithAdamLr(0.1))
+	s := NewStepLR(optm, 1, 0.5)
+	s.Step()
+	w.Sum(0, false).Sum(0, false).Backward()
+	optm.Step()
+	// the first step of adam moves each param by lr*sign(grad)
+	for _, v := range w.Float32Value() {
+		if math.Abs(float64(v)+0.05) > 1e-6 {
+			t.Fatalf("unexpected param %f, expected %f", v, -0.05)
+		}
+	}
+}
+
+func TestOneCycleShortWarmup(t *testing.T) {
+	// pctStart*total == 1 leaves no step for the warmup phase
+	s := NewOneCycle(newOptimizer(), 0.1, 10, 0.1, 25, 1e4)
+	for i := 0; i < 12; i++ {
+		lr := s.GetLr()
+		if math.IsNaN(lr) || lr <= 0 || lr > 0.1 {
+			t.Fatalf("step %d: unexpected lr %f", i, lr)
+		}
+		s.Step()
+	}
+}
+
+func TestReadInvalidState(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := writeState(&buf, &stepState{BaseLr: 0.1, Gamma: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadStepLR(newOptimizer()).ReadFrom(&buf)
+	if !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
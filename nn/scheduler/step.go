@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/lwch/gotorch/optimizer"
+)
+
+type stepState struct {
+	BaseLr   float64
+	StepSize int64
+	Gamma    float64
+	Steps    int64
+}
+
+func (s stepState) validate() error {
+	if s.StepSize <= 0 || s.Steps < 0 {
+		return fmt.Errorf("%w: step_size=%d, steps=%d", ErrInvalidState, s.StepSize, s.Steps)
+	}
+	return nil
+}
+
+// StepLR decays the learning rate by gamma every stepSize steps
+type StepLR struct {
+	base
+	state stepState
+}
+
+var _ Scheduler = &StepLR{}
+
+func NewStepLR(optm optimizer.Optimizer, stepSize int, gamma float64) *StepLR {
+	var s StepLR
+	s.optm = optm
+	s.class = "step"
+	s.state.BaseLr = optm.GetLr()
+	s.state.StepSize = int64(stepSize)
+	s.state.Gamma = gamma
+	if err := s.state.validate(); err != nil {
+		panic(err)
+	}
+	return &s
+}
+
+func LoadStepLR(optm optimizer.Optimizer) Scheduler {
+	return NewStepLR(optm, 1, 1)
+}
+
+func (s *StepLR) lr() float64 {
+	return s.state.BaseLr * math.Pow(s.state.Gamma, float64(s.state.Steps/s.state.StepSize))
+}
+
+func (s *StepLR) Step() {
+	s.state.Steps++
+	s.optm.SetLr(s.lr())
+}
+
+func (s *StepLR) WriteTo(w io.Writer) (int64, error) {
+	return writeState(w, &s.state)
+}
+
+func (s *StepLR) ReadFrom(r io.Reader) (int64, error) {
+	n, err := readState(r, &s.state)
+	if err != nil {
+		return n, err
+	}
+	if err := s.state.validate(); err != nil {
+		return n, err
+	}
+	s.optm.SetLr(s.lr())
+	return n, nil
+}
@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lwch/gotorch/optimizer"
+)
+
+type warmupState struct {
+	BaseLr      float64
+	Warmup      int64
+	StartFactor float64
+	Steps       int64
+}
+
+func (s warmupState) validate() error {
+	if s.Warmup < 0 || s.Steps < 0 {
+		return fmt.Errorf("%w: warmup=%d, steps=%d", ErrInvalidState, s.Warmup, s.Steps)
+	}
+	return nil
+}
+
+// LinearWarmup increases the learning rate linearly from initial*startFactor
+// to the initial value in warmup steps
+type LinearWarmup struct {
+	base
+	state warmupState
+}
+
+var _ Scheduler = &LinearWarmup{}
+
+func NewLinearWarmup(optm optimizer.Optimizer, warmup int, startFactor float64) *LinearWarmup {
+	var s LinearWarmup
+	s.optm = optm
+	s.class = "warmup"
+	s.state.BaseLr = optm.GetLr()
+	s.state.Warmup = int64(warmup)
+	s.state.StartFactor = startFactor
+	if err := s.state.validate(); err != nil {
+		panic(err)
+	}
+	s.optm.SetLr(s.lr())
+	return &s
+}
+
+func LoadLinearWarmup(optm optimizer.Optimizer) Scheduler {
+	var s LinearWarmup
+	s.optm = optm
+	s.class = "warmup"
+	return &s
+}
+
+func (s *LinearWarmup) lr() float64 {
+	if s.state.Steps >= s.state.Warmup {
+		return s.state.BaseLr
+	}
+	pct := float64(s.state.Steps) / float64(s.state.Warmup)
+	return s.state.BaseLr * (s.state.StartFactor + (1-s.state.StartFactor)*pct)
+}
+
+func (s *LinearWarmup) Step() {
+	s.state.Steps++
+	s.optm.SetLr(s.lr())
+}
+
+func (s *LinearWarmup) WriteTo(w io.Writer) (int64, error) {
+	return writeState(w, &s.state)
+}
+
+func (s *LinearWarmup) ReadFrom(r io.Reader) (int64, error) {
+	n, err := readState(r, &s.state)
+	if err != nil {
+		return n, err
+	}
+	if err := s.state.validate(); err != nil {
+		return n, err
+	}
+	s.optm.SetLr(s.lr())
+	return n, nil
+}
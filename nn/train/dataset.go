@@ -0,0 +1,11 @@
+package train
+
+import "github.com/lwch/gotorch/tensor"
+
+// Dataset provides samples by index
+type Dataset interface {
+	// Len returns the count of samples
+	Len() int
+	// Batch builds the input and target tensors of the samples
+	Batch(idx []int) (x, y *tensor.Tensor)
+}
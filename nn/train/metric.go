@@ -0,0 +1,104 @@
+package train
+
+import (
+	"math"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/tensor"
+)
+
+// Metric is updated by each batch and reported at the end of each epoch
+type Metric interface {
+	Name() string
+	Reset()
+	Update(pred, target *tensor.Tensor)
+	Value() float64
+}
+
+func float64Value(t *tensor.Tensor) []float64 {
+	return t.ToDevice(consts.KCPU).ToScalarType(consts.KDouble).Float64Value()
+}
+
+// Accuracy counts the argmax of the last dim of pred which equals to target
+type Accuracy struct {
+	correct, total int
+}
+
+var _ Metric = &Accuracy{}
+
+func NewAccuracy() *Accuracy {
+	return &Accuracy{}
+}
+
+func (m *Accuracy) Name() string {
+	return "accuracy"
+}
+
+func (m *Accuracy) Reset() {
+	m.correct = 0
+	m.total = 0
+}
+
+func (m *Accuracy) Update(pred, target *tensor.Tensor) {
+	shapes := pred.Shapes()
+	classes := int(shapes[len(shapes)-1])
+	probs := float64Value(pred)
+	labels := target.ToDevice(consts.KCPU).ToScalarType(consts.KInt64).Int64Value()
+	for i, label := range labels {
+		row := probs[i*classes : (i+1)*classes]
+		idx := 0
+		for j := range row {
+			if row[j] > row[idx] {
+				idx = j
+			}
+		}
+		if int64(idx) == label {
+			m.correct++
+		}
+		m.total++
+	}
+}
+
+func (m *Accuracy) Value() float64 {
+	if m.total == 0 {
+		return 0
+	}
+	return float64(m.correct) / float64(m.total)
+}
+
+// MeanAbsoluteError is the mean of |pred - target|
+type MeanAbsoluteError struct {
+	sum   float64
+	count int
+}
+
+var _ Metric = &MeanAbsoluteError{}
+
+func NewMeanAbsoluteError() *MeanAbsoluteError {
+	return &MeanAbsoluteError{}
+}
+
+func (m *MeanAbsoluteError) Name() string {
+	return "mae"
+}
+
+func (m *MeanAbsoluteError) Reset() {
+	m.sum = 0
+	m.count = 0
+}
+
+func (m *MeanAbsoluteError) Update(pred, target *tensor.Tensor) {
+	p := float64Value(pred)
+	t := float64Value(target)
+	for i := range p {
+		m.sum += math.Abs(p[i] - t[i])
+	}
+	m.count += len(p)
+}
+
+func (m *MeanAbsoluteError) Value() float64 {
+	if m.count == 0 {
+		return 0
+	}
+	return m.sum / float64(m.count)
+}
@@ -0,0 +1,330 @@
+package train
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"runtime"
+	"time"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/optimizer"
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/nn/net"
+	"github.com/lwch/tnn/nn/scheduler"
+)
+
+// Model is trained by Trainer, net.Sequential implements it
+type Model interface {
+	Forward(x *tensor.Tensor, train bool) *tensor.Tensor
+}
+
+// LossFunc computes the loss of pred and target, e.g. loss.NewMse
+type LossFunc func(pred, target *tensor.Tensor) *tensor.Tensor
+
+// BatchResult is reported at the end of each batch
+type BatchResult struct {
+	Epoch int // begin at 1
+	Batch int // begin at 1
+	Loss  float64
+}
+
+// EpochResult is reported at the end of each epoch and each validation
+type EpochResult struct {
+	Epoch    int // begin at 1
+	Loss     float64
+	Metrics  map[string]float64
+	Duration time.Duration
+}
+
+type Trainer struct {
+	model     Model
+	loss      LossFunc
+	optimizer optimizer.Optimizer
+	scheduler scheduler.Scheduler
+
+	params   []*tensor.Tensor
+	maxNorm  float64
+	normType float64
+
+	epochs     int
+	batchSize  int
+	accumulate int
+	shuffle    bool
+	metrics    []Metric
+	valid      Dataset
+
+	checkpoint      *net.Net
+	checkpointPath  string
+	checkpointEvery int
+
+	onBatchEnd   []func(BatchResult)
+	onEpochEnd   []func(EpochResult)
+	onValidation []func(EpochResult)
+}
+
+type Option func(*Trainer)
+
+// WithEpochs sets the count of epochs, default is 1
+func WithEpochs(n int) Option {
+	return func(t *Trainer) {
+		t.epochs = n
+	}
+}
+
+// WithBatchSize sets the count of samples in each batch, default is 32
+func WithBatchSize(n int) Option {
+	return func(t *Trainer) {
+		t.batchSize = n
+	}
+}
+
+// WithAccumulate steps the optimizer every n batches, default is 1
+func WithAccumulate(n int) Option {
+	return func(t *Trainer) {
+		t.accumulate = n
+	}
+}
+
+// WithShuffle shuffles the samples at the begin of each epoch, default is true
+func WithShuffle(shuffle bool) Option {
+	return func(t *Trainer) {
+		t.shuffle = shuffle
+	}
+}
+
+// WithMetrics computes the metrics on the train and validation dataset
+func WithMetrics(metrics ...Metric) Option {
+	return func(t *Trainer) {
+		t.metrics = append(t.metrics, metrics...)
+	}
+}
+
+// WithValidation runs the validation dataset at the end of each epoch
+func WithValidation(ds Dataset) Option {
+	return func(t *Trainer) {
+		t.valid = ds
+	}
+}
+
+// WithScheduler steps s after each optimizer step, ReduceOnPlateau observes
+// the validation loss (or the train loss without validation) at the end of each epoch
+func WithScheduler(s scheduler.Scheduler) Option {
+	return func(t *Trainer) {
+		t.scheduler = s
+	}
+}
+
+// WithClipGradNorm clips the gradients of params by net.ClipGradNorm before each optimizer step
+func WithClipGradNorm(params []*tensor.Tensor, maxNorm, normType float64) Option {
+	return func(t *Trainer) {
+		t.params = params
+		t.maxNorm = maxNorm
+		t.normType = normType
+	}
+}
+
+// WithCheckpoint saves n with the optimizer to path every `every` epochs
+func WithCheckpoint(n *net.Net, path string, every int) Option {
+	return func(t *Trainer) {
+		t.checkpoint = n
+		t.checkpointPath = path
+		t.checkpointEvery = every
+	}
+}
+
+func OnBatchEnd(fn func(BatchResult)) Option {
+	return func(t *Trainer) {
+		t.onBatchEnd = append(t.onBatchEnd, fn)
+	}
+}
+
+func OnEpochEnd(fn func(EpochResult)) Option {
+	return func(t *Trainer) {
+		t.onEpochEnd = append(t.onEpochEnd, fn)
+	}
+}
+
+func OnValidation(fn func(EpochResult)) Option {
+	return func(t *Trainer) {
+		t.onValidation = append(t.onValidation, fn)
+	}
+}
+
+// WithProgress prints the result of each epoch and validation into w
+func WithProgress(w io.Writer) Option {
+	return func(t *Trainer) {
+		print := func(name string, ret EpochResult) {
+			fmt.Fprintf(w, "%s %d, cost=%s, loss=%f", name, ret.Epoch, ret.Duration.String(), ret.Loss)
+			for _, m := range t.metrics {
+				fmt.Fprintf(w, ", %s=%f", m.Name(), ret.Metrics[m.Name()])
+			}
+			fmt.Fprintln(w)
+		}
+		t.onEpochEnd = append(t.onEpochEnd, func(ret EpochResult) {
+			print("train", ret)
+		})
+		t.onValidation = append(t.onValidation, func(ret EpochResult) {
+			print("validation", ret)
+		})
+	}
+}
+
+func New(model Model, loss LossFunc, optm optimizer.Optimizer, opts ...Option) *Trainer {
+	t := &Trainer{
+		model:      model,
+		loss:       loss,
+		optimizer:  optm,
+		epochs:     1,
+		batchSize:  32,
+		accumulate: 1,
+		shuffle:    true,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Fit trains the model on ds
+func (t *Trainer) Fit(ds Dataset) error {
+	if ds.Len() == 0 {
+		return errors.New("empty dataset")
+	}
+	if t.batchSize <= 0 || t.accumulate <= 0 {
+		return errors.New("batch size and accumulate must be positive")
+	}
+	for epoch := 1; epoch <= t.epochs; epoch++ {
+		ret := t.trainEpoch(epoch, ds)
+		for _, fn := range t.onEpochEnd {
+			fn(ret)
+		}
+		loss := ret.Loss
+		if t.valid != nil && t.valid.Len() > 0 {
+			ret := t.Evaluate(t.valid)
+			ret.Epoch = epoch
+			for _, fn := range t.onValidation {
+				fn(ret)
+			}
+			loss = ret.Loss
+		}
+		if s, ok := t.scheduler.(*scheduler.ReduceOnPlateau); ok {
+			s.Observe(loss)
+		}
+		if t.checkpoint != nil && t.checkpointEvery > 0 &&
+			(epoch%t.checkpointEvery == 0 || epoch == t.epochs) {
+			t.checkpoint.SetOptimizer(t.optimizer)
+			t.checkpoint.SetScheduler(t.scheduler)
+			if err := t.checkpoint.Save(t.checkpointPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *Trainer) batches(ds Dataset, shuffle bool) [][]int {
+	idx := make([]int, ds.Len())
+	for i := range idx {
+		idx[i] = i
+	}
+	if shuffle {
+		rand.Shuffle(len(idx), func(i, j int) {
+			idx[i], idx[j] = idx[j], idx[i]
+		})
+	}
+	var ret [][]int
+	for i := 0; i < len(idx); i += t.batchSize {
+		end := i + t.batchSize
+		if end > len(idx) {
+			end = len(idx)
+		}
+		ret = append(ret, idx[i:end])
+	}
+	return ret
+}
+
+func scalarLike(t *tensor.Tensor, n float32) *tensor.Tensor {
+	return tensor.FromFloat32([]float32{n},
+		tensor.WithShapes(1),
+		tensor.WithDevice(t.DeviceType())).ToScalarType(t.ScalarType())
+}
+
+func lossValue(t *tensor.Tensor) float64 {
+	return t.ToDevice(consts.KCPU).ToScalarType(consts.KDouble).Float64Value()[0]
+}
+
+func (t *Trainer) trainEpoch(epoch int, ds Dataset) EpochResult {
+	begin := time.Now()
+	for _, m := range t.metrics {
+		m.Reset()
+	}
+	batches := t.batches(ds, t.shuffle)
+	var sum float64
+	for i, idx := range batches {
+		x, y := ds.Batch(idx)
+		pred := t.model.Forward(x, true)
+		l := t.loss(pred, y)
+		value := lossValue(l)
+		// the last group may have less than accumulate batches
+		group := t.accumulate
+		if rest := len(batches) - i/t.accumulate*t.accumulate; rest < group {
+			group = rest
+		}
+		if group > 1 {
+			l = l.Div(scalarLike(l, float32(group)))
+		}
+		l.Backward()
+		for _, m := range t.metrics {
+			m.Update(pred, y)
+		}
+		if (i+1)%t.accumulate == 0 || i == len(batches)-1 {
+			if len(t.params) > 0 {
+				net.ClipGradNorm(t.params, t.maxNorm, t.normType)
+			}
+			t.optimizer.Step()
+			if t.scheduler != nil {
+				t.scheduler.Step()
+			}
+			runtime.GC()
+		}
+		sum += value
+		for _, fn := range t.onBatchEnd {
+			fn(BatchResult{Epoch: epoch, Batch: i + 1, Loss: value})
+		}
+	}
+	return t.result(epoch, sum/float64(len(batches)), begin)
+}
+
+// Evaluate computes the loss and metrics on ds without training
+func (t *Trainer) Evaluate(ds Dataset) EpochResult {
+	begin := time.Now()
+	for _, m := range t.metrics {
+		m.Reset()
+	}
+	batches := t.batches(ds, false)
+	var sum float64
+	for _, idx := range batches {
+		x, y := ds.Batch(idx)
+		pred := t.model.Forward(x, false)
+		sum += lossValue(t.loss(pred, y))
+		for _, m := range t.metrics {
+			m.Update(pred, y)
+		}
+	}
+	return t.result(0, sum/float64(len(batches)), begin)
+}
+
+func (t *Trainer) result(epoch int, loss float64, begin time.Time) EpochResult {
+	ret := EpochResult{
+		Epoch:    epoch,
+		Loss:     loss,
+		Metrics:  make(map[string]float64, len(t.metrics)),
+		Duration: time.Since(begin),
+	}
+	for _, m := range t.metrics {
+		ret.Metrics[m.Name()] = m.Value()
+	}
+	return ret
+}
@@ -0,0 +1,132 @@
+package train
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/lwch/gotorch/consts"
+	"github.com/lwch/gotorch/loss"
+	"github.com/lwch/gotorch/optimizer"
+	"github.com/lwch/gotorch/tensor"
+	"github.com/lwch/tnn/nn/layer"
+	"github.com/lwch/tnn/nn/net"
+)
+
+type xorDataset struct{}
+
+func (xorDataset) Len() int {
+	return 4
+}
+
+func (xorDataset) Batch(idx []int) (*tensor.Tensor, *tensor.Tensor) {
+	xs := []float32{0, 0, 0, 1, 1, 0, 1, 1}
+	ys := []float32{0, 1, 1, 0}
+	var x, y []float32
+	for _, i := range idx {
+		x = append(x, xs[i*2:i*2+2]...)
+		y = append(y, ys[i])
+	}
+	return tensor.FromFloat32(x, tensor.WithShapes(int64(len(idx)), 2)),
+		tensor.FromFloat32(y, tensor.WithShapes(int64(len(idx)), 1))
+}
+
+// sumDataset uses the inputs of xorDataset, the target is x1+x2 which can be fitted by a linear layer
+type sumDataset struct{}
+
+func (sumDataset) Len() int {
+	return 4
+}
+
+func (sumDataset) Batch(idx []int) (*tensor.Tensor, *tensor.Tensor) {
+	xs := []float32{0, 0, 0, 1, 1, 0, 1, 1}
+	var x, y []float32
+	for _, i := range idx {
+		x = append(x, xs[i*2:i*2+2]...)
+		y = append(y, xs[i*2]+xs[i*2+1])
+	}
+	return tensor.FromFloat32(x, tensor.WithShapes(int64(len(idx)), 2)),
+		tensor.FromFloat32(y, tensor.WithShapes(int64(len(idx)), 1))
+}
+
+func mse(pred, target *tensor.Tensor) *tensor.Tensor {
+	return loss.NewMse(pred, target)
+}
+
+func TestTrainer(t *testing.T) {
+	seq := net.NewSequential(consts.KCPU,
+		layer.NewLinear("hidden", 2, 8),
+		layer.NewLinear("output", 8, 1))
+	var batches, epochs int
+	trainer := New(seq, func(pred, target *tensor.Tensor) *tensor.Tensor {
+		return loss.NewMse(pred, target)
+	}, optimizer.NewAdam(seq.Params()),
+		WithEpochs(3),
+		WithBatchSize(1),
+		WithAccumulate(2),
+		WithMetrics(NewMeanAbsoluteError()),
+		OnBatchEnd(func(BatchResult) {
+			batches++
+		}),
+		OnEpochEnd(func(ret EpochResult) {
+			epochs++
+			if _, ok := ret.Metrics["mae"]; !ok {
+				t.Fatal("missing metric")
+			}
+		}))
+	if err := trainer.Fit(xorDataset{}); err != nil {
+		t.Fatal(err)
+	}
+	if batches != 12 || epochs != 3 {
+		t.Fatalf("unexpected batches=%d, epochs=%d", batches, epochs)
+	}
+}
+
+func TestTrainerLossDecrease(t *testing.T) {
+	seq := net.NewSequential(consts.KCPU, layer.NewLinear("output", 2, 1))
+	var losses []float64
+	trainer := New(seq, mse, optimizer.NewAdam(seq.Params(), optimizer.WithAdamLr(0.05)),
+		WithEpochs(100),
+		WithBatchSize(1),
+		WithAccumulate(3), // the last group of each epoch has only one batch
+		OnEpochEnd(func(ret EpochResult) {
+			losses = append(losses, ret.Loss)
+		}))
+	if err := trainer.Fit(sumDataset{}); err != nil {
+		t.Fatal(err)
+	}
+	first, last := losses[0], losses[len(losses)-1]
+	if last >= first/10 {
+		t.Fatalf("loss should decrease, first=%f, last=%f", first, last)
+	}
+}
+
+func TestTrainerCheckpoint(t *testing.T) {
+	seq := net.NewSequential(consts.KCPU, layer.NewLinear("output", 2, 1))
+	path := filepath.Join(t.TempDir(), "model")
+	trainer := New(seq, mse, optimizer.NewAdam(seq.Params()),
+		WithEpochs(2),
+		WithBatchSize(2),
+		WithCheckpoint(&seq.Net, path, 1))
+	if err := trainer.Fit(sumDataset{}); err != nil {
+		t.Fatal(err)
+	}
+	loaded := net.New(consts.KCPU)
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.GetOptimizer() == nil {
+		t.Fatal("optimizer should be saved in the checkpoint")
+	}
+	want, got := seq.Params(), loaded.Params()
+	if len(want) != len(got) {
+		t.Fatalf("unexpected params count %d, expected %d", len(got), len(want))
+	}
+	for i := range want {
+		a, b := want[i].Float32Value(), got[i].Float32Value()
+		for j := range a {
+			if a[j] != b[j] {
+				t.Fatalf("unexpected value of param %d at %d: %f != %f", i, j, b[j], a[j])
+			}
+		}
+	}
+}